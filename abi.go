@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// cStd is the c standard the header is read as, such as c11, passed to the host compiler as -std for the predefined macros.
+	cStd = ""
+	// targetOS and targetArch are the platform the header is read for, by the names of go, such as windows and arm64,
+	// which decide the sizes of the types, such as 4 bytes of long on windows. They are the ones of the host when empty.
+	targetOS   = ""
+	targetArch = ""
+	// targetTriple is the platform as the triple of the c compilers, such as aarch64-apple-darwin, in place of targetOS and targetArch.
+	targetTriple = ""
+)
+
+// cStds are the values of --std, which the parser reads the headers of mkl and the other blases as.
+var cStds = []string{"c99", "c11", "c17", "c18", "c2x", "c23", "gnu99", "gnu11", "gnu17", "gnu18", "gnu2x", "gnu23"}
+
+// callingConventions are the calling conventions of msvc the headers for windows declare the routines with, such as __cdecl of MKL_CALL_CONV,
+// which the parser does not know, so they are defined as nothing, as is __declspec, such as __declspec(dllimport),
+// unless the predefined macros of the compiler define them.
+var callingConventions = []string{"__cdecl", "_cdecl", "__stdcall", "_stdcall", "__fastcall", "__vectorcall", "__thiscall", "__clrcall"}
+
+// callingConventionsPredefined is the macros defining the calling conventions and __declspec as nothing.
+func callingConventionsPredefined() string {
+	r := ""
+	for _, c := range callingConventions {
+		r += fmt.Sprintf("\n#ifndef %s\n#define %s\n#endif\n", c, c)
+	}
+
+	return r + "\n#ifndef __declspec\n#define __declspec(x)\n#endif\n"
+}
+
+// tripleArchs are the go names of the architectures of the triples, by their first field.
+var tripleArchs = map[string]string{
+	"x86_64":      "amd64",
+	"amd64":       "amd64",
+	"aarch64":     "arm64",
+	"arm64":       "arm64",
+	"i386":        "386",
+	"i486":        "386",
+	"i586":        "386",
+	"i686":        "386",
+	"arm":         "arm",
+	"armv7":       "arm",
+	"armv7a":      "arm",
+	"armv7l":      "arm",
+	"riscv64":     "riscv64",
+	"powerpc64le": "ppc64le",
+	"ppc64le":     "ppc64le",
+	"s390x":       "s390x",
+	"loongarch64": "loong64",
+}
+
+// tripleOSes are the go names of the operating systems by the fields of the triples naming them, such as apple for darwin.
+var tripleOSes = map[string]string{
+	"linux":   "linux",
+	"darwin":  "darwin",
+	"apple":   "darwin",
+	"macos":   "darwin",
+	"windows": "windows",
+	"mingw":   "windows",
+	"msvc":    "windows",
+	"freebsd": "freebsd",
+	"netbsd":  "netbsd",
+	"openbsd": "openbsd",
+	"illumos": "illumos",
+	"solaris": "illumos",
+}
+
+// addABIFlags adds the flags of the c standard and the platform the header is read for.
+func addABIFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&cStd, "std", cStd, "c standard to read the header as, one of "+strings.Join(cStds, ", ")+", or the default of the host compiler")
+	cmd.PersistentFlags().StringVar(&targetOS, "target-os", targetOS, "operating system to read the header for by its go name, such as windows, which decides the sizes of the types, such as long. default to the host")
+	cmd.PersistentFlags().StringVar(&targetArch, "target-arch", targetArch, "architecture to read the header for by its go name, such as arm64. default to the host")
+	cmd.PersistentFlags().StringVar(&targetTriple, "target", targetTriple, "platform to read the header for as the triple of the c compilers, such as x86_64-pc-windows-msvc, in place of --target-os and --target-arch")
+	cmd.MarkFlagsMutuallyExclusive("target", "target-os")
+	cmd.MarkFlagsMutuallyExclusive("target", "target-arch")
+}
+
+// parserTarget is the operating system and the architecture the header is read for by their go names,
+// from --target, or --target-os and --target-arch, and otherwise the host.
+func parserTarget() (goos string, goarch string) {
+	goos, goarch = targetOS, targetArch
+	if targetTriple != "" {
+		goos, goarch = parseTriple(targetTriple)
+	}
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+
+	return goos, goarch
+}
+
+// parseTriple is the go names of the operating system and the architecture of a triple, such as linux and arm64 for aarch64-linux-gnu.
+func parseTriple(triple string) (goos string, goarch string) {
+	fields := strings.Split(triple, "-")
+	goarch, knownArch := tripleArchs[fields[0]]
+	if !knownArch {
+		failf(exitInput, "--target %s has an unknown architecture %s", triple, fields[0])
+	}
+	for _, field := range fields[1:] {
+		// such as darwin23.1.0 and macos14
+		field = strings.TrimRight(field, "0123456789.")
+		if os, found := tripleOSes[field]; found {
+			return os, goarch
+		}
+	}
+	failf(exitInput, "--target %s has no known operating system", triple)
+
+	return "", ""
+}
+
+// parserOptions are the options of the host compiler for the predefined macros, such as -std=c11.
+func parserOptions() []string {
+	if cStd == "" {
+		return nil
+	}
+	if !slices.Contains(cStds, cStd) {
+		failf(exitInput, "--std %s should be one of %s", cStd, strings.Join(cStds, ", "))
+	}
+
+	return []string{fmt.Sprintf("-std=%s", cStd)}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// accelerate reads the headers of the accelerate framework of macOS, cblas_new.h and lapack.h of vecLib,
+// with the new lapack interface of macOS 13.3, instead of mkl.h.
+var accelerate = false
+
+// accelerateIncludes are the headers the outputs include with --accelerate, Accelerate/Accelerate.h including the ones of vecLib,
+// after complex.h, which cgo spells the _Complex types of lapack.h with.
+var accelerateIncludes = []string{"complex.h", "Accelerate/Accelerate.h"}
+
+// accelerateNullability are the nullability qualifiers of clang the headers of accelerate annotate the pointers with,
+// which the translation of the header does not know, so they are defined as nothing.
+var accelerateNullability = []string{"_Nullable", "_Nonnull", "_Null_unspecified", "__nullable", "__nonnull"}
+
+// defaultAccelerateHeaders reads cblas_new.h, and lapack.h without --extra-header, from vecLib of the sdk of SDKROOT,
+// or of the command line tools.
+func defaultAccelerateHeaders() {
+	sdk := os.Getenv("SDKROOT")
+	if sdk == "" {
+		sdk = "/Library/Developer/CommandLineTools/SDKs/MacOSX.sdk"
+	}
+	headers := filepath.Join(sdk, "System/Library/Frameworks/Accelerate.framework/Frameworks/vecLib.framework/Headers")
+	mklPath = filepath.Join(headers, "cblas_new.h")
+	if len(extraHeaders) == 0 {
+		extraHeaders = []string{filepath.Join(headers, "lapack.h")}
+	}
+}
+
+// accelerateMacros select the new lapack interface, and with --ilp64 its 64-bit integers,
+// both when reading the header and in the generated c code.
+func accelerateMacros() []string {
+	if ilp64 {
+		return []string{"ACCELERATE_NEW_LAPACK", "ACCELERATE_LAPACK_ILP64"}
+	}
+
+	return []string{"ACCELERATE_NEW_LAPACK"}
+}
+
+// acceleratePredefined is the macros the headers of accelerate are read with.
+func acceleratePredefined() string {
+	r := ""
+	for _, m := range accelerateMacros() {
+		r += fmt.Sprintf("\n#define %s 1\n", m)
+	}
+	for _, q := range accelerateNullability {
+		r += fmt.Sprintf("\n#define %s\n", q)
+	}
+
+	return r
+}
+
+// checkAccelerateOutput fails unless the output calls the routines through the header, as rust with bindgen, c++, c, go, swift and r do.
+// kotlin declares the routines in its def file instead.
+// accelerate declares the routines of the new lapack interface under the names of the old one, and links them to other symbols,
+// such as cblas_dgemm$NEWLAPACK, which the outputs binding the symbols by name would miss.
+func checkAccelerateOutput() {
+	if !accelerate {
+		return
+	}
+	if forJulia || forPython || forCffi || forJava || forZig || forFortran || forHaskell || forOCaml || forKotlin ||
+		forLua || forNode || forPascal || forCrystal || forAda {
+		failf(exitInput, "--accelerate generates rust, c++, c, go, swift or r, which call the routines through the header of accelerate")
+	}
+}
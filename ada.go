@@ -0,0 +1,135 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed ada.tmpl
+var adaTmplText string
+
+var (
+	forAda         = false
+	adaPackageName = "MKL_Routines"
+)
+
+func (*tmplInput) AdaPackageName() string {
+	return adaPackageName
+}
+
+// AdaSelf is the ada type of the float type.
+func (f *funcDef) AdaSelf() string {
+	if f.is32() {
+		return "Float"
+	}
+	return "Long_Float"
+}
+
+// getAdaParamType returns the ada type of c type t, with self as the float type.
+// Types that are not known are passed as System.Address.
+func getAdaParamType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.adaType
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		return "access System.Address"
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return "access " + self
+	case "double", "float", "const double", "const float":
+		return self
+	case "char", "const char":
+		return "Interfaces.C.char"
+	case "const char *":
+		return "access Interfaces.C.char"
+	case "int *", "const int *":
+		return "access Interfaces.C.int"
+	case "int64_t *", "const int64_t *":
+		return "access Interfaces.Integer_64"
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "Interfaces.C.int"
+	}
+	if isEnum(t) {
+		return "Interfaces.C.int"
+	}
+	if isStruct(t) {
+		return structTag(t)
+	}
+
+	return "System.Address"
+}
+
+func getAdaReturnType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.adaType
+	}
+
+	switch t {
+	case "void":
+		return ""
+	case "float", "double":
+		return self
+	default:
+		if isEnum(t) {
+			return "Interfaces.C.int"
+		}
+		if isStruct(t) {
+			return structTag(t)
+		}
+		return "System.Address"
+	}
+}
+
+// AdaFields are the components of the record, which is passed by copy like the c struct.
+func (s *structDef) AdaFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		r = append(r, fmt.Sprintf("%s : %s;", adaParamName(field.name), getAdaParamType(field.typeName, fieldSelf(field, "Long_Float", "Float"))))
+	}
+
+	return r
+}
+
+var adaKeywords = map[string]struct{}{
+	"abort": {}, "abs": {}, "abstract": {}, "accept": {}, "access": {}, "aliased": {}, "all": {}, "and": {}, "array": {},
+	"at": {}, "begin": {}, "body": {}, "case": {}, "constant": {}, "declare": {}, "delay": {}, "delta": {}, "digits": {},
+	"do": {}, "else": {}, "elsif": {}, "end": {}, "entry": {}, "exception": {}, "exit": {}, "for": {}, "function": {},
+	"generic": {}, "goto": {}, "if": {}, "in": {}, "interface": {}, "is": {}, "limited": {}, "loop": {}, "mod": {},
+	"new": {}, "not": {}, "null": {}, "of": {}, "or": {}, "others": {}, "out": {}, "overriding": {}, "package": {},
+	"parallel": {}, "pragma": {}, "private": {}, "procedure": {}, "protected": {}, "raise": {}, "range": {}, "record": {},
+	"rem": {}, "renames": {}, "requeue": {}, "return": {}, "reverse": {}, "select": {}, "separate": {}, "some": {},
+	"subtype": {}, "synchronized": {}, "tagged": {}, "task": {}, "terminate": {}, "then": {}, "type": {}, "until": {},
+	"use": {}, "when": {}, "while": {}, "with": {}, "xor": {},
+}
+
+// adaParamName makes the c parameter name a valid ada parameter name.
+func adaParamName(name string) string {
+	if _, isKeyword := adaKeywords[strings.ToLower(name)]; isKeyword {
+		return name + "_Param"
+	}
+
+	return name
+}
+
+// AdaSpec is the subprogram specification of the routine called name, with self as the float type.
+func (f *funcDef) AdaSpec(name string, self string) string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, fmt.Sprintf("%s : %s", adaParamName(p.name), getAdaParamType(p.typeName, self)))
+	}
+	params := ""
+	if len(r) > 0 {
+		params = fmt.Sprintf(" (%s)", strings.Join(r, "; "))
+	}
+
+	ret := getAdaReturnType(f.ReturnType, self)
+	if ret == "" {
+		return fmt.Sprintf("procedure %s%s", name, params)
+	}
+
+	return fmt.Sprintf("function %s%s return %s", name, params, ret)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"modernc.org/cc/v4"
+)
+
+// bannerPattern matches the first line of the banner, which tells the outputs of the tool from the hand-written files.
+var bannerPattern = regexp.MustCompile(`gen-mkl-wrapper \S+ from `)
+
+// force overwrites the files without the banner of the tool, which are otherwise taken as hand-written and kept.
+var force = false
+
+// outputFlags are the flags the output is generated with, as they are given on the command line, in the config
+// or by the environment variables, which are recorded in the banner of the outputs to regenerate them.
+var outputFlags []string
+
+// bannerSkippedFlags are the flags that do not change the outputs, which are left out of their banners,
+// so check compares the outputs with the ones generated with or without them.
+var bannerSkippedFlags = map[string]struct{}{
+	"verbose": {},
+	"quiet":   {},
+	"strict":  {},
+	"force":   {},
+	"prune":   {},
+	"depfile": {},
+	"report":  {},
+	"config":  {},
+
+	"header-cache":     {},
+	"header-cache-dir": {},
+	"jobs":             {},
+}
+
+// recordFlags records the flags set for the command in outputFlags, in the order of their names.
+func recordFlags(cmd *cobra.Command) {
+	outputFlags = []string{}
+	// Changed rather than Visit, which keeps visiting the flags of the shared ones restored after a target of the config
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if _, skipped := bannerSkippedFlags[flag.Name]; skipped || !flag.Changed {
+			return
+		}
+
+		switch v := flag.Value.(type) {
+		case pflag.SliceValue:
+			for _, s := range v.GetSlice() {
+				outputFlags = append(outputFlags, fmt.Sprintf("--%s=%s", flag.Name, shellQuote(s)))
+			}
+		default:
+			if flag.Value.Type() == "bool" && flag.Value.String() == "true" {
+				outputFlags = append(outputFlags, "--"+flag.Name)
+				return
+			}
+			outputFlags = append(outputFlags, fmt.Sprintf("--%s=%s", flag.Name, shellQuote(flag.Value.String())))
+		}
+	})
+}
+
+// shellQuote is s quoted for the shells if it has characters other than the ones of the paths and the names.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// toolVersion is the version of the module the tool is built from, such as v0.3.0, or (devel) for the local builds.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "(devel)"
+	}
+
+	return info.Main.Version
+}
+
+// mklVersion is the version of mkl from the version macros of the header, such as 2024.0.1, or empty if it has none.
+func mklVersion(ast *cc.AST) string {
+	major, hasMajor := constantValue(ast, "__INTEL_MKL__")
+	if !hasMajor {
+		return ""
+	}
+	minor, _ := constantValue(ast, "__INTEL_MKL_MINOR__")
+	update, _ := constantValue(ast, "__INTEL_MKL_UPDATE__")
+
+	return fmt.Sprintf("%d.%d.%d", major, minor, update)
+}
+
+// openblasVersion is the version of openblas from OPENBLAS_VERSION of openblas_config.h, such as OpenBLAS 0.3.26,
+// or empty if it has none.
+func openblasVersion(ast *cc.AST) string {
+	m, isMacro := ast.Macros["OPENBLAS_VERSION"]
+	if !isMacro || m.IsFnLike || len(m.ReplacementList()) != 1 {
+		return ""
+	}
+	version, err := strconv.Unquote(m.ReplacementList()[0].SrcStr())
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(version)
+}
+
+// headerVersion is the header of ast in the banner, with the version of mkl or openblas it declares.
+func headerVersion(ast *cc.AST) string {
+	if version := mklVersion(ast); version != "" {
+		return "the header of mkl " + version
+	} else if version := openblasVersion(ast); version != "" {
+		return "the header of " + version
+	}
+
+	return "a header without the version of mkl"
+}
+
+// newBanner is the lines of the comment at the top of the outputs, recording how they are generated:
+// the version of the tool and of the header, the flags, and the hash of the function list.
+func newBanner(header string, desiredFuncList []string) []string {
+	hash := sha256.Sum256([]byte(strings.Join(desiredFuncList, "\n")))
+
+	return []string{
+		fmt.Sprintf("gen-mkl-wrapper %s from %s", toolVersion(), header),
+		"flags: " + strings.Join(outputFlags, " "),
+		fmt.Sprintf("function list sha256: %x", hash),
+	}
+}
+
+// Banner is the lines of the comment at the top of the outputs, recording how they are generated.
+func (i *tmplInput) Banner() []string {
+	return i.banner
+}
+
+// checkOverwrite fails unless the files are missing, empty or generated by the tool, before any of them is written,
+// so a mistyped --output does not overwrite a hand-written file.
+func checkOverwrite(files []generatedFile) {
+	if force {
+		return
+	}
+
+	for _, f := range files {
+		// the output of --in-place keeps the hand-written code around its markers, and --prune checks the files it prunes itself
+		if f.path == "-" || (inPlace && f.path == outputFile) || f.pruned {
+			continue
+		}
+		existing, err := os.ReadFile(f.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		orFail(exitOutput, err)
+		if len(existing) > 0 && !bannerPattern.Match(existing) {
+			failf(exitOutput, "%s is not generated by gen-mkl-wrapper, use --force to overwrite it", f.path)
+		}
+	}
+}
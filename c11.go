@@ -0,0 +1,36 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed c11.tmpl
+var c11TmplText string
+
+var forC11 = false
+
+// C11Dispatch is the expression selecting the routine by the type of the float argument with _Generic,
+// or of the type of the precision without floats, such as fftw_plan of fftw_execute.
+// When the routine takes neither, the float64 one is always called.
+func (f *GoFuncPair) C11Dispatch() string {
+	arg, isArray := f.dispatchArg()
+	switch {
+	case arg == nil:
+		for i, p := range f.Float32Func.args {
+			if _, isPrecision := precisionTypedef(p.typeName); isPrecision {
+				return fmt.Sprintf("_Generic((%s), %s: %s, %s: %s)", p.name,
+					strings.TrimPrefix(p.typeName, "const "), f.Float32Func.RawName,
+					strings.TrimPrefix(f.Float64Func.args[i].typeName, "const "), f.Float64Func.RawName)
+			}
+		}
+		return f.Float64Func.RawName
+	case isArray:
+		return fmt.Sprintf(
+			"_Generic((%s), float *: %s, const float *: %s, double *: %s, const double *: %s)",
+			arg.name, f.Float32Func.RawName, f.Float32Func.RawName, f.Float64Func.RawName, f.Float64Func.RawName)
+	default:
+		return fmt.Sprintf("_Generic((%s), float: %s, double: %s)", arg.name, f.Float32Func.RawName, f.Float64Func.RawName)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CxxNeedsComplex is true when the complex routines of the output or of its groups are selected, which take std::complex.
+func (i *tmplInput) CxxNeedsComplex() bool {
+	return len(i.C64Funcs()) > 0 || slices.ContainsFunc(i.groups, (*tmplInput).CxxNeedsComplex)
+}
+
+// cxxComplexSelf is the std::complex type of the complex type.
+func (f *funcDef) cxxComplexSelf() string {
+	if f.elem == complex64Elem {
+		return "std::complex<float>"
+	}
+	return "std::complex<double>"
+}
+
+// HasComplexParams is true when the complex routine has MKL_Complex8/MKL_Complex16 in its parameters.
+// Otherwise the complex numbers are passed as void pointers, and the single and double precision routines cannot be overloaded.
+func (f *funcDef) HasComplexParams() bool {
+	for _, p := range f.args {
+		if strings.Contains(p.typeName, "MKL_Complex") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cxxComplexTypes are the c complex types the overloads take as std::complex, besides the void pointers of the complex numbers.
+func cxxComplexTypes() []string {
+	return append([]string{"MKL_Complex8", "MKL_Complex16", "float _Complex", "double _Complex"}, complexTypedefNames()...)
+}
+
+// isCxxComplex is true if c type t is or points to a complex type of cxxComplexTypes.
+func isCxxComplex(t string) bool {
+	return slices.ContainsFunc(cxxComplexTypes(), func(complexType string) bool { return strings.Contains(t, complexType) })
+}
+
+// cxxComplexType is c type t with the complex numbers as std::complex, and if the type is changed.
+func (f *funcDef) cxxComplexType(t string) (string, bool) {
+	self := f.cxxComplexSelf()
+	for _, mkltype := range append(cxxComplexTypes(), "void") {
+		if strings.Contains(t, mkltype) {
+			return strings.Replace(t, mkltype, self, 1), true
+		}
+	}
+
+	return t, false
+}
+
+// CcParams are the parameters of the c++ wrapper, with the types from the type map.
+// The parameters taking the VSL constants are of their types, such as const VslBrng brng of vslNewStream.
+func (f *funcDef) CcParams() string {
+	return f.cParams(func(p funcArg) string {
+		if vsl := f.vslParamType(p); vsl != "" {
+			return strings.Replace(p.declType, strings.TrimPrefix(p.declType, "const "), vsl, 1)
+		}
+		return ccParamType(p.declType)
+	})
+}
+
+// TakesVSLConstants is true when the routine has parameters taking the VSL constants, such as brng of vslNewStream.
+// The c++ output overloads the plain ones with the parameters of their types.
+func (f *funcDef) TakesVSLConstants() bool {
+	return slices.ContainsFunc(f.args, func(p funcArg) bool { return f.vslParamType(p) != "" })
+}
+
+// CcVSLInput are the arguments of the overload of TakesVSLConstants to the routine, with the VSL constants cast back to the declared types,
+// so the routine it calls is the one of the header rather than the overload.
+func (f *funcDef) CcVSLInput() string {
+	ps := []string{}
+	for _, p := range f.args {
+		if f.vslParamType(p) != "" {
+			ps = append(ps, fmt.Sprintf("static_cast<%s>(%s)", strings.TrimPrefix(p.declType, "const "), p.name))
+			continue
+		}
+		ps = append(ps, p.name)
+	}
+
+	return strings.Join(ps, ",")
+}
+
+// CxxComplexParams are the parameters of the overload taking std::complex. The complex ones are read from their resolved types,
+// so the typedefs of the complex types, such as lapack_complex_float, are std::complex as well, and the others are as declared.
+func (f *funcDef) CxxComplexParams() string {
+	ps := []string{}
+	for _, p := range f.args {
+		t := ccParamType(p.declType)
+		if complexType, isComplex := f.cxxComplexType(p.typeName); isComplex {
+			t = complexType
+		}
+		switch {
+		case p.fn != nil:
+			ps = append(ps, p.fn.declare(p.name))
+		case strings.HasSuffix(t, "[]"):
+			ps = append(ps, fmt.Sprintf("%s %s[%s]", strings.TrimSuffix(t, "[]"), p.name, p.extent))
+		default:
+			ps = append(ps, fmt.Sprintf("%s %s", t, p.name))
+		}
+	}
+
+	return strings.Join(ps, ",")
+}
+
+// CxxComplexOverload is true when the overload taking std::complex takes other parameters than the one taking the types of the header,
+// which it is generated next to when the routine has complex parameters, as c++ cannot overload on the return type alone.
+func (f *funcDef) CxxComplexOverload() bool {
+	return !f.HasComplexParams() || f.CxxComplexParams() != f.CcParams()
+}
+
+// CxxComplexInput are the arguments to the complex routine, reinterpret_cast from std::complex to the MKL complex types.
+func (f *funcDef) CxxComplexInput() string {
+	ps := []string{}
+	for _, p := range f.args {
+		t := strings.TrimSuffix(p.typeName, "[]")
+		isArray := t != p.typeName || strings.HasSuffix(t, "*")
+		switch {
+		case strings.Contains(t, "void"):
+			// std::complex pointers convert to void pointers.
+			ps = append(ps, p.name)
+		// cast to the type as declared, such as float _Complex of lapacke.h of openblas or cuComplex of cublas
+		case isCxxComplex(t) && isArray:
+			decl := strings.TrimSuffix(p.declType, "[]")
+			ps = append(ps, fmt.Sprintf("reinterpret_cast<%s>(%s)", strings.TrimSuffix(decl, " *")+" *", p.name))
+		case isCxxComplex(t):
+			ps = append(ps, fmt.Sprintf("*reinterpret_cast<const %s *>(&%s)", strings.TrimPrefix(p.declType, "const "), p.name))
+		default:
+			ps = append(ps, p.name)
+		}
+	}
+
+	return strings.Join(ps, ",")
+}
+
+// CxxComplexReturnType is the return type of the overload taking std::complex, the complex numbers as std::complex
+// and the other types as declared in the header, as the overload taking the types of the header returns them.
+func (f *funcDef) CxxComplexReturnType() string {
+	if isCxxComplex(f.ReturnType) {
+		t, _ := f.cxxComplexType(f.ReturnType)
+		return t
+	}
+
+	return f.CcReturnType()
+}
+
+// CxxComplexBody calls the complex routine and converts its result to std::complex.
+func (f *funcDef) CxxComplexBody() string {
+	call := fmt.Sprintf("%s(%s)", f.RawName, f.CxxComplexInput())
+	switch {
+	case !f.HasReturn():
+		return call + ";"
+	case isCxxComplex(f.ReturnType):
+		return fmt.Sprintf("auto r = %s;\n    return *reinterpret_cast<%s *>(&r);", call, f.CxxComplexReturnType())
+	default:
+		return fmt.Sprintf("return %s;", call)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// the complex routines of lapacke.h and cblas.h of openblas, declared with the c99 complex types and their typedefs
+const complexHeader = `
+typedef long long lapack_int;
+typedef float _Complex lapack_complex_float;
+typedef double _Complex lapack_complex_double;
+lapack_int LAPACKE_cpotrf(int matrix_layout, char uplo, lapack_int n, lapack_complex_float *a, lapack_int lda);
+lapack_int LAPACKE_zpotrf(int matrix_layout, char uplo, lapack_int n, lapack_complex_double *a, lapack_int lda);
+float _Complex cblas_cdotc(const int N, const float _Complex *X, const int incX, const float _Complex *Y, const int incY);
+double _Complex cblas_zdotc(const int N, const double _Complex *X, const int incX, const double _Complex *Y, const int incY);
+`
+
+// the caller calls the overloads taking std::complex, which return the types declared, such as lapack_int of long long
+const complexCaller = `#include <type_traits>
+#include "mkl.hpp"
+
+lapack_int factorize(std::complex<float> *a, std::complex<double> *b) {
+    static_assert(std::is_same<decltype(LAPACKE_potrf(101, 'U', 2, a, 2)), lapack_int>::value, "returns lapack_int");
+    return LAPACKE_potrf(101, 'U', 2, a, 2) + LAPACKE_potrf(101, 'U', 2, b, 2);
+}
+
+std::complex<double> dot(const std::complex<double> *x, const std::complex<float> *y) {
+    return cblas_dotc(2, x, 1, x, 1) + std::complex<double>(cblas_dotc(2, y, 1, y, 1));
+}
+`
+
+func TestCxxComplexOverloads(t *testing.T) {
+	cxx, err := exec.LookPath("g++")
+	if err != nil {
+		if cxx, err = exec.LookPath("clang++"); err != nil {
+			t.Skip("neither g++ nor clang++ is in PATH")
+		}
+	}
+
+	dir, files := generateFromHeader(t, complexHeader, "LAPACKE_%potrf\ncblas_%dotc\n", &forC, "mkl.hpp")
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	caller := filepath.Join(dir, "caller.cc")
+	if err := os.WriteFile(caller, []byte(complexCaller), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command(cxx, "-std=c++17", "-fsyntax-only", "-I", dir, caller).CombinedOutput(); err != nil {
+		t.Fatalf("the generated c++ fails to compile: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed cffi.tmpl
+var cffiTmplText string
+
+var forCffi = false
+
+// CffiSelf is the c type of the float type.
+func (f *funcDef) CffiSelf() string {
+	if f.is32() {
+		return "float"
+	}
+	return "double"
+}
+
+// CffiCallParams are the arguments forwarded to the cffi function, with arrays converted to c buffers.
+func (f *funcDef) CffiCallParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, isSelf := getPyParamType(p.typeName, f.PySelf())
+		switch {
+		case isSelf && strings.HasPrefix(t, "POINTER("):
+			r = append(r, fmt.Sprintf(`_as_ptr(%s, "%s[]")`, p.name, f.CffiSelf()))
+		case t == "c_char", t == "c_char_p":
+			r = append(r, fmt.Sprintf("_as_char(%s)", p.name))
+		default:
+			r = append(r, p.name)
+		}
+	}
+
+	return strings.Join(r, ", ")
+}
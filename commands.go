@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// addCommands adds the subcommands to cmd, which share its flags. cmd itself generates the bindings as generate does,
+// so the command lines without a subcommand keep working.
+func addCommands(cmd *cobra.Command) {
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "generate",
+			Short: "generate the bindings, as gen-mkl-wrapper without a subcommand does",
+			Args:  cobra.NoArgs,
+			Run:   run,
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "list the routines the function list selects from the header, without generating the bindings",
+			Args:  cobra.NoArgs,
+			Run:   runList,
+		},
+		&cobra.Command{
+			Use:   "describe ROUTINE...",
+			Short: "print how the routines are read from the header and generated for rust, go and c++",
+			Long: `print the entry selecting the routines, the names they are generated under, and the types of their parameters
+as declared in the header, as resolved, and in rust, go and c++.
+The routines are selected by the function list, or without one, by the entries guessed for them, such as LAPACKE_*gesvd for LAPACKE_dgesvd.`,
+			Args: cobra.MinimumNArgs(1),
+			Run:  runDescribe,
+		},
+		&cobra.Command{
+			Use:   "check",
+			Short: "generate the bindings in memory and print the unified diff against the existing files, failing if they differ",
+			Args:  cobra.NoArgs,
+			Run:   runCheck,
+		},
+		&cobra.Command{
+			Use:   "ir",
+			Short: "write the routines, constants, enums and structs the function list selects as json, to the output or stdout",
+			Args:  cobra.NoArgs,
+			Run:   runIR,
+		},
+	)
+}
+
+// runList prints the routines the function list selects, with the names they are generated under,
+// the lines of the function list selecting them, and their declarations in the header.
+func runList(cmd *cobra.Command, args []string) {
+	flist, input := loadRoutines()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ROUTINE\tWRAPPER\tENTRY\tDECLARATION")
+	for _, f := range input.funcDefs {
+		// the declarations spanning several lines in the header are printed on one
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.RawName, f.BetterName, flist.lineOf(f.RawName), strings.Join(strings.Fields(f.Declaration), " "))
+	}
+	orPanic(w.Flush())
+}
+
+// runCheck generates the output, or the ones of the targets of the config, in memory, and prints the unified diff from the files on disk to the generated ones.
+// It exits with 1 if any of them differs, or is missing, so the checked in bindings can be kept up to date in ci.
+func runCheck(cmd *cobra.Command, args []string) {
+	if outputFile == "-" {
+		failf(exitInput, "check compares the output with the file on disk, which -o - does not name")
+	}
+
+	differs := false
+	for _, f := range generateAll(cmd) {
+		existing, err := os.ReadFile(f.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failf(exitOutput, "failed to read %s: %v", f.path, err)
+		}
+		if diff := unifiedDiff(f.path, f.path+" (generated)", string(existing), string(f.content)); diff != "" {
+			fmt.Print(diff)
+			differs = true
+		}
+	}
+
+	if differs {
+		os.Exit(1)
+	}
+}
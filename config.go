@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// configPath is the json, yaml or toml file of the flags and the function list, which can be checked into a repository.
+	configPath = ""
+	// configRoutines are the entries of the function list in the routines of the config.
+	configRoutines []string
+)
+
+// defaultConfigNames are the configs read from the working directory without --config, the first one found.
+var defaultConfigNames = []string{"gen-mkl-wrapper.json", "gen-mkl-wrapper.yaml", "gen-mkl-wrapper.yml", "gen-mkl-wrapper.toml"}
+
+// envPrefix is the prefix of the environment variables setting the flags.
+const envPrefix = "GEN_MKL_"
+
+// applyConfig sets the flags from the json, yaml or toml file of --config, by its extension, keyed by the names of the flags, with the function list
+// under routines, such as
+//
+//	{
+//	  "mkl-header": "/opt/intel/oneapi/mkl/latest/include/mkl.h",
+//	  "output": "mkl.go",
+//	  "for-go": true,
+//	  "header-define": ["MKL_DIRECT_CALL"],
+//	  "routines": ["cblas_*gemm", "LAPACKE_*potrf"]
+//	}
+//
+// or the same keys in yaml or toml.
+// The flags given on the command line take precedence over the environment variables, which take precedence over the config.
+// Without --config, gen-mkl-wrapper.json, .yaml, .yml or .toml in the working directory is read if there is one.
+func applyConfig(cmd *cobra.Command, _ []string) {
+	applyEnv(cmd)
+
+	if configPath == "" {
+		for _, name := range defaultConfigNames {
+			if _, err := os.Stat(name); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			configPath = name
+			infof("reading the config %s in the working directory", name)
+			break
+		}
+		if configPath == "" {
+			return
+		}
+	}
+
+	config, err := decodeConfig(configPath, readFile(configPath))
+	if err != nil {
+		failf(exitInput, "failed to read the config %s: %v", configPath, err)
+	}
+
+	for name, raw := range config {
+		if name == "routines" {
+			if err := json.Unmarshal(raw, &configRoutines); err != nil {
+				failf(exitInput, "routines of the config %s should be a list of strings: %v", configPath, err)
+			}
+			continue
+		}
+		if name == "targets" {
+			if err := json.Unmarshal(raw, &configTargets); err != nil {
+				failf(exitInput, "targets of the config %s should be a list of the flags of each target: %v", configPath, err)
+			}
+			continue
+		}
+
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || name == "config" {
+			failf(exitInput, "%s of the config %s is not a flag", name, configPath)
+		}
+		if flag.Changed {
+			continue
+		}
+		for _, v := range configValues(name, raw) {
+			orFail(exitInput, cmd.Flags().Set(name, v))
+		}
+	}
+}
+
+// decodeConfig is the config in content as the json values of its keys, read as yaml for .yaml and .yml,
+// as toml for .toml and as json otherwise.
+func decodeConfig(path string, content []byte) (map[string]json.RawMessage, error) {
+	config := make(map[string]json.RawMessage)
+
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	// the values of yaml and toml are taken as the ones of json, so both are checked alike
+	for name, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		config[name] = raw
+	}
+
+	return config, nil
+}
+
+// flagAliases are the other names of the flags, such as --include-path for --header-include-dir,
+// which the command line and the config take alike.
+var flagAliases = map[string]string{
+	"include-path": "header-include-dir",
+	"define":       "header-define",
+}
+
+// normalizeFlagName is the name of the flag of alias name, or name itself.
+func normalizeFlagName(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	if flag, isAlias := flagAliases[name]; isAlias {
+		return pflag.NormalizedName(flag)
+	}
+
+	return pflag.NormalizedName(name)
+}
+
+// envName is the environment variable of flag name, which is GEN_MKL_ followed by the name in upper case with _ for -,
+// without the mkl- of the name, such as GEN_MKL_HEADER for --mkl-header and GEN_MKL_ILP64 for --ilp64.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(name, "mkl-"), "-", "_"))
+}
+
+// applyEnv sets the flags not given on the command line from their environment variables, where the flags taking
+// several values take them separated by commas, such as GEN_MKL_INPUT=blas.txt,lapack.txt.
+func applyEnv(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		value, found := os.LookupEnv(envName(flag.Name))
+		if !found || flag.Changed || flag.Name == "help" {
+			return
+		}
+
+		// the values of the string slices are split by commas when they are set, but not the ones of the string arrays
+		values := []string{value}
+		if flag.Value.Type() == "stringArray" {
+			values = strings.Split(value, ",")
+		}
+		for _, v := range values {
+			if err := cmd.Flags().Set(flag.Name, v); err != nil {
+				failf(exitInput, "%s should be a value of --%s: %v", envName(flag.Name), flag.Name, err)
+			}
+		}
+	})
+}
+
+// configValues are the values of the flag in the config as they are given on the command line,
+// with one value for each element of a list, such as the headers of --include.
+func configValues(name string, raw json.RawMessage) []string {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+
+	r := make([]string, 0, len(list))
+	for _, v := range list {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			r = append(r, s)
+			continue
+		}
+		// booleans and numbers are given as they are written
+		t := strings.TrimSpace(string(v))
+		if strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[") || t == "null" {
+			failf(exitInput, "%s of the config %s should be a string, a boolean, a number, or a list of them", name, configPath)
+		}
+		r = append(r, t)
+	}
+
+	return r
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// the same config in each of the formats read by --config
+var configFormats = map[string]string{
+	"gen-mkl.json": `{
+  "mkl-header": "/opt/intel/oneapi/mkl/latest/include/mkl.h",
+  "header-define": ["MKL_DIRECT_CALL", "MKL_ILP64"],
+  "for-go": true,
+  "verbose": 2,
+  "routines": ["cblas_*gemm", "LAPACKE_*potrf = cholesky_factor"],
+  "targets": [{"for-python": true, "output": "mkl.py"}]
+}`,
+	"gen-mkl.yaml": `mkl-header: /opt/intel/oneapi/mkl/latest/include/mkl.h
+header-define: [MKL_DIRECT_CALL, MKL_ILP64]
+for-go: true
+verbose: 2
+routines:
+  - cblas_*gemm
+  - LAPACKE_*potrf = cholesky_factor
+targets:
+  - for-python: true
+    output: mkl.py
+`,
+	"gen-mkl.toml": `mkl-header = "/opt/intel/oneapi/mkl/latest/include/mkl.h"
+header-define = ["MKL_DIRECT_CALL", "MKL_ILP64"]
+for-go = true
+verbose = 2
+routines = ["cblas_*gemm", "LAPACKE_*potrf = cholesky_factor"]
+
+[[targets]]
+for-python = true
+output = "mkl.py"
+`,
+}
+
+func TestConfigFormats(t *testing.T) {
+	wantValues := map[string][]string{
+		"mkl-header":    {"/opt/intel/oneapi/mkl/latest/include/mkl.h"},
+		"header-define": {"MKL_DIRECT_CALL", "MKL_ILP64"},
+		"for-go":        {"true"},
+		"verbose":       {"2"},
+	}
+	wantRoutines := []string{"cblas_*gemm", "LAPACKE_*potrf = cholesky_factor"}
+	wantTargets := []map[string]json.RawMessage{{"for-python": json.RawMessage("true"), "output": json.RawMessage(`"mkl.py"`)}}
+
+	for path, content := range configFormats {
+		config, err := decodeConfig(path, []byte(content))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		if len(config) != len(wantValues)+2 {
+			t.Errorf("%s has the keys %v", path, reflect.ValueOf(config).MapKeys())
+		}
+		for name, want := range wantValues {
+			if got := configValues(name, config[name]); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s of %s is %q, want %q", name, path, got, want)
+			}
+		}
+		var routines []string
+		if err := json.Unmarshal(config["routines"], &routines); err != nil || !reflect.DeepEqual(routines, wantRoutines) {
+			t.Errorf("routines of %s are %q, want %q: %v", path, routines, wantRoutines, err)
+		}
+		var targets []map[string]json.RawMessage
+		if err := json.Unmarshal(config["targets"], &targets); err != nil || !reflect.DeepEqual(targets, wantTargets) {
+			t.Errorf("targets of %s are %s, want %s: %v", path, config["targets"], wantTargets, err)
+		}
+	}
+}
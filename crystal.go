@@ -0,0 +1,185 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed crystal.tmpl
+var crystalTmplText string
+
+var (
+	forCrystal        = false
+	crystalModuleName = "MKLRoutines"
+	crystalLibrary    = "mkl_rt"
+)
+
+func (*tmplInput) CrystalModuleName() string {
+	return crystalModuleName
+}
+
+func (*tmplInput) CrystalLibrary() string {
+	return crystalLibrary
+}
+
+// CrystalName is the name of the wrapper method, crystal methods cannot start with an upper case letter.
+func (f *funcDef) CrystalName() string {
+	return lowerLeading(f.BetterName)
+}
+
+// CrystalFun is the name of the fun in the lib block, aliased to the c symbol when it starts with an upper case letter.
+func (f *funcDef) CrystalFun() string {
+	name := lowerLeading(f.RawName)
+	if name == f.RawName {
+		return name
+	}
+
+	return fmt.Sprintf("%s = %s", name, f.RawName)
+}
+
+// CrystalFunName is the name of the fun in the lib block.
+func (f *funcDef) CrystalFunName() string {
+	return lowerLeading(f.RawName)
+}
+
+// CrystalSelf is the crystal type of the float type.
+func (f *funcDef) CrystalSelf() string {
+	if f.is32() {
+		return "Float32"
+	}
+	return "Float64"
+}
+
+// getCrystalParamType returns the crystal type of c type t, with self as the float type.
+// Types that are not known are passed as Void*.
+func getCrystalParamType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.crystalType
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		return "Void**"
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return self + "*"
+	case "double", "float", "const double", "const float":
+		return self
+	case "char", "const char":
+		return "LibC::Char"
+	case "const char *":
+		return "LibC::Char*"
+	case "int *", "const int *":
+		return "Int32*"
+	case "int64_t *", "const int64_t *":
+		return "Int64*"
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "Int32"
+	}
+	if isEnum(t) {
+		return "Int32"
+	}
+	if isStruct(t) {
+		return "LibMKL::" + crystalStructName(structTag(t))
+	}
+
+	return "Void*"
+}
+
+// crystalStructName is the name of the struct in the lib block, which must be a constant, such as MatrixDescr for matrix_descr.
+func crystalStructName(tag string) string {
+	r := []string{}
+	for _, part := range strings.Split(tag, "_") {
+		r = append(r, strings.ToUpper(part[:1])+part[1:])
+	}
+
+	return strings.Join(r, "")
+}
+
+// CrystalName is the name of the struct in the lib block.
+func (s *structDef) CrystalName() string {
+	return crystalStructName(s.Name)
+}
+
+// CrystalFields are the fields of the struct in the lib block.
+func (s *structDef) CrystalFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		r = append(r, fmt.Sprintf("%s : %s", crystalParamName(field.name), getCrystalParamType(field.typeName, fieldSelf(field, "Float64", "Float32"))))
+	}
+
+	return r
+}
+
+// CrystalReturn is the return type of the routine.
+func (f *funcDef) CrystalReturn() string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.crystalType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return "Void"
+	case "float", "double":
+		return f.CrystalSelf()
+	default:
+		if isEnum(f.ReturnType) {
+			return "Int32"
+		}
+		if isStruct(f.ReturnType) {
+			return "LibMKL::" + crystalStructName(structTag(f.ReturnType))
+		}
+		return "Void*"
+	}
+}
+
+// CrystalDefReturn is the return type of the wrapper method, which is Nil instead of Void.
+func (f *funcDef) CrystalDefReturn() string {
+	if !f.HasReturn() {
+		return "Nil"
+	}
+
+	return f.CrystalReturn()
+}
+
+var crystalKeywords = map[string]struct{}{
+	"abstract": {}, "alias": {}, "annotation": {}, "as": {}, "asm": {}, "begin": {}, "break": {}, "case": {},
+	"class": {}, "def": {}, "do": {}, "else": {}, "elsif": {}, "end": {}, "ensure": {}, "enum": {}, "extend": {},
+	"false": {}, "for": {}, "fun": {}, "if": {}, "in": {}, "include": {}, "instance_sizeof": {}, "is_a?": {}, "lib": {},
+	"macro": {}, "module": {}, "next": {}, "nil": {}, "of": {}, "offsetof": {}, "out": {}, "pointerof": {},
+	"private": {}, "protected": {}, "require": {}, "rescue": {}, "return": {}, "select": {}, "self": {}, "sizeof": {},
+	"struct": {}, "super": {}, "then": {}, "true": {}, "type": {}, "typeof": {}, "uninitialized": {}, "union": {},
+	"unless": {}, "until": {}, "verbatim": {}, "when": {}, "while": {}, "with": {}, "yield": {},
+}
+
+// crystalParamName makes the c parameter name a valid crystal variable name.
+func crystalParamName(name string) string {
+	name = lowerLeading(name)
+	if _, isKeyword := crystalKeywords[name]; isKeyword {
+		return name + "_"
+	}
+
+	return name
+}
+
+// CrystalParams are the parameters of the routine.
+func (f *funcDef) CrystalParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, fmt.Sprintf("%s : %s", crystalParamName(p.name), getCrystalParamType(p.typeName, f.CrystalSelf())))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// CrystalCallArgs are the arguments forwarded to the lib fun.
+func (f *funcDef) CrystalCallArgs() string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, crystalParamName(p.name))
+	}
+
+	return strings.Join(r, ", ")
+}
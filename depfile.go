@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// depfilePath is the file of --depfile, the make rule of the outputs on the files read to generate them.
+var depfilePath = ""
+
+// readPaths are the files read to generate the outputs, the headers included and the files of the flags,
+// such as the function list and the config, in the order they are first read.
+var readPaths []string
+
+// readPathsMu guards readPaths, which the headers read at once by --jobs add to.
+var readPathsMu sync.Mutex
+
+// recordRead adds path to the files read to generate the outputs.
+func recordRead(path string) {
+	readPathsMu.Lock()
+	defer readPathsMu.Unlock()
+	if !slices.Contains(readPaths, path) {
+		readPaths = append(readPaths, path)
+	}
+}
+
+// headerFS opens the headers from the disk as the translation of the header without it does,
+// recording the ones it includes.
+type headerFS struct{}
+
+func (headerFS) Open(name string) (fs.File, error) {
+	f, err := os.Open(filepath.FromSlash(name))
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := f.Stat(); err == nil && !fi.IsDir() {
+		recordRead(name)
+	}
+
+	return f, nil
+}
+
+// depfileEscape escapes the characters of a path that make and ninja read as the syntax of the rule.
+func depfileEscape(path string) string {
+	return strings.NewReplacer(" ", `\ `, "#", `\#`, "$", "$$").Replace(path)
+}
+
+// writeDepfile writes the make rule of the files written on the files read, such as
+//
+//	mkl.rs: routines.txt /opt/intel/oneapi/mkl/latest/include/mkl.h /opt/intel/oneapi/mkl/latest/include/mkl_cblas.h
+//
+// so make, ninja and the build systems using them regenerate the outputs when the headers or the function list change.
+func writeDepfile(path string, files []generatedFile) {
+	if outputFile == "-" {
+		failf(exitInput, "--depfile makes the rule of the outputs on disk, which -o - does not name")
+	}
+
+	targets := []string{}
+	for _, f := range files {
+		// the files removed or emptied by --prune are not the outputs of the rule
+		if !f.pruned {
+			targets = append(targets, depfileEscape(f.path))
+		}
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(targets, " "))
+	b.WriteByte(':')
+	for _, p := range readPaths {
+		b.WriteString(" \\\n  ")
+		b.WriteString(depfileEscape(p))
+	}
+	b.WriteByte('\n')
+
+	orFail(exitOutput, os.WriteFile(path, []byte(b.String()), 0o666))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+var (
+	skipDeprecated   bool
+	failOnDeprecated bool
+)
+
+// deprecatedAttributes are the spellings of the attribute MKL_DEPRECATED expands to.
+var deprecatedAttributes = map[string]struct{}{
+	"deprecated":     {},
+	"__deprecated__": {},
+}
+
+// hasDeprecatedAttribute reports whether any of the __attribute__ in the list is deprecated.
+func hasDeprecatedAttribute(l *cc.AttributeSpecifierList) bool {
+	for ; l != nil; l = l.AttributeSpecifierList {
+		if l.AttributeSpecifier == nil {
+			continue
+		}
+		for v := l.AttributeSpecifier.AttributeValueList; v != nil; v = v.AttributeValueList {
+			if v.AttributeValue == nil {
+				continue
+			}
+			if _, found := deprecatedAttributes[v.AttributeValue.Token.SrcStr()]; found {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isDeprecated reports whether the declarator of l of the declaration d is marked deprecated, either before the return type
+// like MKL_DEPRECATED void cblas_xxx(...), or after the parameters.
+func isDeprecated(d *cc.Declaration, l *cc.InitDeclaratorList) bool {
+	for s := d.DeclarationSpecifiers; s != nil; s = s.DeclarationSpecifiers {
+		if s.Case == cc.DeclarationSpecifiersAttr && hasDeprecatedAttribute(s.AttributeSpecifierList) {
+			return true
+		}
+	}
+
+	if l != nil {
+		if hasDeprecatedAttribute(l.AttributeSpecifierList) ||
+			(l.InitDeclarator != nil && hasDeprecatedAttribute(l.InitDeclarator.AttributeSpecifierList)) {
+			return true
+		}
+	}
+
+	return hasDeprecatedAttribute(d.AttributeSpecifierList)
+}
+
+// filterDeprecated warns about the deprecated routines, drops them with --skip-deprecated
+// and fails with --fail-on-deprecated.
+func filterDeprecated(funcs []funcDef) []funcDef {
+	r := make([]funcDef, 0, len(funcs))
+	deprecated := make([]string, 0)
+	for _, f := range funcs {
+		if !f.deprecated {
+			r = append(r, f)
+			continue
+		}
+
+		deprecated = append(deprecated, f.RawName)
+		if failOnDeprecated {
+			continue
+		}
+		if skipDeprecated {
+			warnf("skipping %s, which is deprecated", f.RawName)
+			continue
+		}
+		warnf("%s is deprecated", f.RawName)
+		r = append(r, f)
+	}
+
+	if failOnDeprecated && len(deprecated) > 0 {
+		failf(exitInput, "deprecated routines are selected: %s", strings.Join(deprecated, ", "))
+	}
+
+	return r
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"modernc.org/cc/v4"
+)
+
+// runDescribe prints the routines of args as they are read from the header and generated for rust, go and c++.
+// Without a function list, each routine is selected by the entry guessed for it, such as LAPACKE_*gesvd for LAPACKE_dgesvd.
+func runDescribe(cmd *cobra.Command, args []string) {
+	// the routines are guessed from all of the header
+	ccast := translateHeader("")
+
+	content := ""
+	if hasFuncList() {
+		content = readFuncLists()
+	} else {
+		entries := []string{}
+		for _, name := range args {
+			entries = append(entries, guessEntry(ccast, name))
+		}
+		content = strings.Join(entries, "\n")
+	}
+	flist, funcs := selectRoutines(ccast, content)
+
+	for i, name := range args {
+		f := findRoutine(flist, funcs, name)
+		if f == nil {
+			failf(exitInput, "%s is not a routine of the header the function list selects", name)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		describeRoutine(os.Stdout, flist, f)
+	}
+}
+
+// guessEntry is the entry of the function list selecting routine name with the routines on its other types,
+// which is name with a letter replaced by the first wildcard whose routines are all declared in the header,
+// such as LAPACKE_*gesvd for LAPACKE_dgesvd, or name itself if there is none.
+func guessEntry(ast *cc.AST, name string) string {
+	for _, w := range wildcards {
+		for _, l := range w.letters {
+			for i := 0; i < len(name); i++ {
+				if !strings.HasPrefix(name[i:], l.letter) {
+					continue
+				}
+				entry := name[:i] + w.char + name[i+len(l.letter):]
+				routines := expandName(entry)
+				if _, found := routines[name]; found && allDeclared(ast, routines) {
+					return entry
+				}
+			}
+		}
+	}
+
+	return name
+}
+
+// allDeclared is true when all of routines are declared in the header.
+func allDeclared(ast *cc.AST, routines map[string]funcName) bool {
+	for name := range routines {
+		if _, found := ast.Scope.Nodes[name]; !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findRoutine is the routine of funcs named name in the header, or by the entry without wildcards it is a macro of.
+func findRoutine(flist *funcListInput, funcs []funcDef, name string) *funcDef {
+	for i := range funcs {
+		if funcs[i].RawName == name || flist.plainNames[funcs[i].RawName] == name {
+			return &funcs[i]
+		}
+	}
+
+	return nil
+}
+
+// describeRoutine writes the names of routine f, and the types of its parameters and its return in the header and in the outputs.
+func describeRoutine(out io.Writer, flist *funcListInput, f *funcDef) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	elem := elemName(f.elem)
+	if elem == "" {
+		elem = "none, generated as a plain function"
+	}
+	group := f.group
+	if group == "" {
+		group = "none"
+	}
+	fmt.Fprintf(w, "routine\t%s\n", f.RawName)
+	fmt.Fprintf(w, "entry\t%s\n", flist.lineOf(f.RawName))
+	fmt.Fprintf(w, "wrapper\t%s, %s in go\n", f.BetterName, f.GoName())
+	fmt.Fprintf(w, "type\t%s\n", elem)
+	fmt.Fprintf(w, "group\t%s\n", group)
+	fmt.Fprintf(w, "declaration\t%s\n", strings.Join(strings.Fields(f.Declaration), " "))
+	orPanic(w.Flush())
+	fmt.Fprintln(out)
+
+	rustParams := f.SelfParams()
+	goParams := f.GoParams()
+	fmt.Fprintln(w, "PARAMETER\tDECLARED\tRESOLVED\tRUST\tGO\tC++")
+	for i, p := range f.args {
+		_, rustType, _ := strings.Cut(rustParams[i], ": ")
+		_, goType, _ := strings.Cut(goParams[i], " ")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.name, p.declType, p.typeName, rustType, goType, ccParamType(p.declType))
+	}
+	rustReturn, _ := f.rustReturnType()
+	goReturn, _ := f.goReturnType()
+	fmt.Fprintf(w, "(return)\t%s\t%s\t%s\t%s\t%s\n", f.declReturnType, f.ReturnType, rustReturn, goReturn, f.CcReturnType())
+	orPanic(w.Flush())
+}
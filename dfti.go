@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// dftiDescriptorRoutines are the routines committing, computing with, copying and freeing the DFTI_DESCRIPTOR_HANDLE
+// DftiCreateDescriptor_?_1d or DftiCreateDescriptor_?_md creates.
+// The precision is set when the descriptor is created, so they are generated as plain functions.
+// DftiComputeForward and DftiComputeBackward are variadic, and are declared with their fixed parameters, which is the in-place transform.
+var dftiDescriptorRoutines = []string{
+	"DftiCommitDescriptor",
+	"DftiComputeForward",
+	"DftiComputeBackward",
+	"DftiCopyDescriptor",
+	"DftiFreeDescriptor",
+}
+
+// addDescriptorRoutines selects the descriptor routines if any DftiCreateDescriptor routine is selected,
+// since a descriptor cannot be used or freed without them. They are in the group of those, if they are all in the same group.
+// Only the ones header ast declares are selected, or all of them if ast is nil, as addStreamRoutines does.
+func (f *funcListInput) addDescriptorRoutines(ast *cc.AST) {
+	groups := make(map[string]struct{})
+	for name, fn := range f.names {
+		if strings.HasPrefix(name, "DftiCreateDescriptor_") {
+			groups[fn.group] = struct{}{}
+		}
+	}
+
+	if len(groups) == 0 {
+		return
+	}
+
+	for _, name := range dftiDescriptorRoutines {
+		if _, found := f.names[name]; !found && !f.isExcluded(name) && declaresRoutine(ast, name) {
+			f.names[name] = funcName{betterName: name, elem: noElem, out: noElem, group: onlyGroup(groups)}
+			f.lines[name] = "(added for DftiCreateDescriptor)"
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes in the unified diffs.
+const diffContext = 3
+
+// maxDiffCells is the largest table of the longest common subsequence of the changed lines,
+// beyond which they are shown as removed and added as a whole.
+const maxDiffCells = 1 << 24
+
+// diffLine is a line of a diff, with kind ' ' for unchanged, '-' for removed and '+' for added lines.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// splitLines is the lines of content, where a last line without the newline is marked so.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, "\n") {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+
+	return lines
+}
+
+// diffLines is the lines of a and b as unchanged, removed and added lines.
+func diffLines(a []string, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	r := make([]diffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		r = append(r, diffLine{' ', line})
+	}
+	r = append(r, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		r = append(r, diffLine{' ', line})
+	}
+
+	return r
+}
+
+// diffMiddle is the diff of a and b by their longest common subsequence.
+func diffMiddle(a []string, b []string) []diffLine {
+	r := make([]diffLine, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			r = append(r, diffLine{'-', line})
+		}
+		for _, line := range b {
+			r = append(r, diffLine{'+', line})
+		}
+		return r
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			r = append(r, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			r = append(r, diffLine{'-', a[i]})
+			i++
+		default:
+			r = append(r, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	return r
+}
+
+// unifiedDiff is the unified diff from content a of file aName to content b of file bName, or empty if they are the same.
+func unifiedDiff(aName string, bName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// the line numbers in a and b before each of lines
+	aLine, bLine := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for k, l := range lines {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if l.kind != '+' {
+			aLine[k+1]++
+		}
+		if l.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	for k := 0; k < len(lines); {
+		if lines[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		// a hunk takes the changes up to diffContext lines before the first and after the last,
+		// which are less than 2*diffContext unchanged lines apart
+		start := max(k-diffContext, 0)
+		end := k
+		for unchanged := 0; end < len(lines) && unchanged <= 2*diffContext; end++ {
+			if lines[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// end is past the unchanged lines after the last change, keep diffContext of them
+		last := end - 1
+		for last > k && lines[last].kind == ' ' {
+			last--
+		}
+		end = min(last+1+diffContext, len(lines))
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[end]), hunkRange(bLine[start], bLine[end]))
+		for _, l := range lines[start:end] {
+			sb.WriteByte(l.kind)
+			sb.WriteString(l.text)
+		}
+		k = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange is the range of the lines from after line from to line to in a hunk header, which starts at from for no lines.
+func hunkRange(from int, to int) string {
+	if to-from == 1 {
+		return fmt.Sprintf("%d", to)
+	}
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+var (
+	// importEnums makes the rust output import the enums, such as CBLAS_LAYOUT, from the provider crate instead of defining them.
+	importEnums = false
+	// enumNames are the names of the enums the selected routines take.
+	enumNames = make(map[string]struct{})
+	// otherEnumNames are the enums the selected routines return, such as sparse_status_t, or have as the fields of the structs they take.
+	// rust imports them from the provider crate, while the other outputs pass them as int like enumNames.
+	otherEnumNames = make(map[string]struct{})
+	// enumTags are the enums named only by their tag, such as enum DFTI_CONFIG_VALUE, which cgo calls C.enum_DFTI_CONFIG_VALUE.
+	enumTags = make(map[string]struct{})
+)
+
+// enumDef is an enum typedef of the header taken by the selected routines, such as CBLAS_LAYOUT.
+type enumDef struct {
+	Name   string
+	Consts []constDef
+}
+
+// baseType is the type t is made of, with the pointers, arrays and const removed, such as CBLAS_TRANSPOSE for const CBLAS_TRANSPOSE *.
+func baseType(t string) string {
+	t = strings.TrimSuffix(t, "[]")
+	for {
+		pointee, _, isPointer := cutPointer(t)
+		if !isPointer {
+			break
+		}
+		t = strings.TrimSuffix(pointee, " const")
+	}
+
+	return strings.TrimPrefix(t, "const ")
+}
+
+// enumType is the enum type the typedef name resolves to, or nil if it is not an enum.
+// The name can also be the tag of an enum without a typedef, such as DFTI_CONFIG_VALUE of the dfti routines,
+// which are then recorded in enumTags.
+// The typedefs come first, for the enums named the same by their tag and their typedef,
+// such as typedef enum CBLAS_LAYOUT {...} CBLAS_LAYOUT of the reference cblas.h.
+func enumType(ast *cc.AST, name string) *cc.EnumType {
+	for _, n := range ast.Scope.Nodes[name] {
+		if n, isDeclarator := n.(*cc.Declarator); isDeclarator {
+			if e, isEnum := n.Type().(*cc.EnumType); isEnum && n.IsTypename() {
+				return e
+			}
+		}
+	}
+	for _, n := range ast.Scope.Nodes[name] {
+		if n, isTag := n.(*cc.EnumSpecifier); isTag {
+			if e, isEnum := n.Type().(*cc.EnumType); isEnum && len(e.Enumerators()) > 0 {
+				enumTags[name] = struct{}{}
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// retrieveEnums are the enums the routines take, sorted by name, with the enumerators in the order they are declared.
+func retrieveEnums(ast *cc.AST, funcs []funcDef) []*enumDef {
+	enums := make(map[string]*enumDef)
+	for _, f := range funcs {
+		for _, p := range f.args {
+			name := baseType(p.typeName)
+			if _, done := enums[name]; done {
+				continue
+			}
+			e := enumType(ast, name)
+			if e == nil {
+				continue
+			}
+			def := &enumDef{Name: name}
+			for _, en := range e.Enumerators() {
+				if v, ok := constValue(en.Value()); ok {
+					def.Consts = append(def.Consts, constDef{Name: en.Token.SrcStr(), Value: v})
+				}
+			}
+			enums[name] = def
+			enumNames[name] = struct{}{}
+		}
+	}
+
+	for _, f := range funcs {
+		if _, taken := enums[f.ReturnType]; !taken && enumType(ast, f.ReturnType) != nil {
+			otherEnumNames[f.ReturnType] = struct{}{}
+		}
+	}
+
+	r := make([]*enumDef, 0, len(enums))
+	for _, e := range enums {
+		r = append(r, e)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name < r[j].Name })
+
+	return r
+}
+
+// cEnumName is how c spells the enum t, with enum before the enums named only by their tag.
+func cEnumName(t string) string {
+	if _, isTag := enumTags[t]; isTag {
+		return "enum " + t
+	}
+
+	return t
+}
+
+// goCblasEnums are the cblas enums the go template declares with their enumerators as constants, such as CblasRowMajor of CBLAS_LAYOUT.
+var goCblasEnums = map[string]bool{
+	"CBLAS_LAYOUT":    true,
+	"CBLAS_SIDE":      true,
+	"CBLAS_DIAG":      true,
+	"CBLAS_TRANSPOSE": true,
+	"CBLAS_UPLO":      true,
+}
+
+// isGoEnum is true if t is an enum the go output declares as a type with its enumerators as constants, such as cublasOperation_t,
+// which the wrappers take and convert to the cgo type. The cblas enums of goCblasEnums are declared by the go template itself,
+// and the other ones, such as CBLAS_ORDER of accelerate, are passed as their cgo types, since their enumerators are the same.
+func isGoEnum(t string) bool {
+	_, isEnum := enumNames[t]
+
+	return isEnum && (!strings.HasPrefix(t, "CBLAS_") || goCblasEnums[t])
+}
+
+// GoEnums are the enums the go output declares, see isGoEnum, other than the ones of the template.
+func (i *tmplInput) GoEnums() []*enumDef {
+	r := []*enumDef{}
+	for _, e := range i.Enums {
+		if isGoEnum(e.Name) && !goCblasEnums[e.Name] {
+			r = append(r, e)
+		}
+	}
+
+	return r
+}
+
+// GoCblasEnums is true if the header declares the enumerators of the cblas enums, such as CblasRowMajor,
+// which the go output declares as the constants of CBLAS_LAYOUT and the like.
+func (i *tmplInput) GoCblasEnums() bool {
+	return i.goCblasEnums
+}
+
+// enumAlias is an enumerator of the same value as an earlier one, such as CUBLAS_OP_HERMITAN for CUBLAS_OP_C of cublas.
+type enumAlias struct {
+	Name string
+	// Of is the earlier enumerator of the value.
+	Of string
+}
+
+// Variants are the enumerators of the enum with the values not taken by earlier ones, which rust allows only once.
+func (e *enumDef) Variants() []constDef {
+	r := []constDef{}
+	for _, c := range e.Consts {
+		if !slices.ContainsFunc(r, func(v constDef) bool { return v.Value == c.Value }) {
+			r = append(r, c)
+		}
+	}
+
+	return r
+}
+
+// Aliases are the enumerators of the enum left out of the variants, which rust defines as the associated constants of the enum.
+func (e *enumDef) Aliases() []enumAlias {
+	r := []enumAlias{}
+	variants := e.Variants()
+	for _, c := range e.Consts {
+		if slices.Contains(variants, c) {
+			continue
+		}
+		i := slices.IndexFunc(variants, func(v constDef) bool { return v.Value == c.Value })
+		r = append(r, enumAlias{Name: c.Name, Of: variants[i].Name})
+	}
+
+	return r
+}
+
+// RustEnums are the enums defined in the rust output, none if they are imported from the provider crate.
+func (i *tmplInput) RustEnums() []*enumDef {
+	if importEnums {
+		return nil
+	}
+
+	return i.Enums
+}
+
+// isRustEnum is true if the rust type t is, or points to, an enum defined in the rust output.
+func isRustEnum(t string) bool {
+	if importEnums {
+		return false
+	}
+	_, isEnum := enumNames[rustPointee(t)]
+
+	return isEnum
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed fortran.tmpl
+var fortranTmplText string
+
+var (
+	forFortran        = false
+	fortranModuleName = "mkl_routines"
+)
+
+func (*tmplInput) FortranModuleName() string {
+	return fortranModuleName
+}
+
+// FortranName is the name of the procedure, which is the c routine for the ones in the generic interfaces,
+// and the name in the function list for the plain ones.
+func (f *funcDef) FortranName() string {
+	if f.elem == noElem {
+		return f.BetterName
+	}
+
+	return f.RawName
+}
+
+// FortranKind is the iso_c_binding kind of the float type.
+func (f *funcDef) FortranKind() string {
+	if f.is32() {
+		return "c_float"
+	}
+	return "c_double"
+}
+
+// getFortranParamType returns the type declaration of dummy argument for c type t.
+// Types that are not known are passed as type(c_ptr).
+func getFortranParamType(t string, kind string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return fmt.Sprintf("integer(%s), value", integer.fortranKind)
+	}
+
+	if _, isFunc := funcPointerTypes[t]; isFunc {
+		return "type(c_funptr), value"
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		// the stream vslNewStream or the descriptor DftiCreateDescriptor creates is written to the c_ptr passed by reference
+		return "type(c_ptr), intent(inout)"
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return fmt.Sprintf("real(%s), intent(in)", kind)
+	case "double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("real(%s), intent(inout)", kind)
+	case "double", "float", "const double", "const float":
+		return fmt.Sprintf("real(%s), value", kind)
+	case "char", "const char":
+		return "character(kind=c_char), value"
+	case "const char *":
+		// the character arguments of the fortran symbols, such as the transa of dgemm_
+		return "character(kind=c_char), intent(in)"
+	case "int *":
+		return "integer(c_int), intent(inout)"
+	case "const int *":
+		return "integer(c_int), intent(in)"
+	case "int64_t *":
+		return "integer(c_int64_t), intent(inout)"
+	case "const int64_t *":
+		return "integer(c_int64_t), intent(in)"
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "integer(c_int), value"
+	}
+
+	switch {
+	case isEnum(t):
+		return "integer(c_int), value"
+	case isStruct(t):
+		return fmt.Sprintf("type(%s), value", structTag(t))
+	}
+
+	return "type(c_ptr), value"
+}
+
+// FortranFields are the declarations of the components of the interoperable derived type.
+func (s *structDef) FortranFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		t := strings.TrimSuffix(getFortranParamType(field.typeName, fieldSelf(field, "c_double", "c_float")), ", value")
+		r = append(r, fmt.Sprintf("%s :: %s", t, field.name))
+	}
+
+	return r
+}
+
+// FortranDecls are the declarations of the dummy arguments, arrays are assumed-size.
+func (f *funcDef) FortranDecls() []string {
+	r := []string{}
+	for _, p := range f.args {
+		t := getFortranParamType(p.typeName, f.FortranKind())
+		switch p.typeName {
+		case "const double *", "const float *", "const float[]", "const double[]",
+			"double *", "float *", "float[]", "double[]",
+			"int *", "const int *", "int64_t *", "const int64_t *":
+			r = append(r, fmt.Sprintf("%s :: %s(*)", t, p.name))
+		default:
+			r = append(r, fmt.Sprintf("%s :: %s", t, p.name))
+		}
+	}
+
+	return r
+}
+
+// FortranResult is the type of the function result, only valid when the routine returns a value.
+func (f *funcDef) FortranResult() string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return fmt.Sprintf("integer(%s)", integer.fortranKind)
+	}
+
+	switch f.ReturnType {
+	case "float", "double":
+		return fmt.Sprintf("real(%s)", f.FortranKind())
+	}
+	if isEnum(f.ReturnType) {
+		return "integer(c_int)"
+	}
+	if isStruct(f.ReturnType) {
+		return fmt.Sprintf("type(%s)", structTag(f.ReturnType))
+	}
+
+	return "type(c_ptr)"
+}
+
+// FortranArgs is the dummy argument list, one argument per continued line.
+func (f *funcDef) FortranArgs() string {
+	r := ""
+	for i, p := range f.args {
+		if i > 0 {
+			r += ", &\n                "
+		}
+		r += p.name
+	}
+
+	return r
+}
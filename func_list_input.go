@@ -1,77 +1,613 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"slices"
 	"strings"
+
+	"modernc.org/cc/v4"
 )
 
 type funcListInput struct {
-	forFloat64      map[string]string
-	forFloat32      map[string]string
+	// names maps the routine names to their better names and element types.
+	names           map[string]funcName
 	desiredFuncList []string
+	// constNames are the names without wildcards, which are the constants to extract from the header, such as VML_HA,
+	// unless they are routines.
+	constNames []string
+	// plainNames are the entries without wildcards by the routines they call, which are the same
+	// unless the entry is a macro of the routine, such as mkl_get_max_threads of MKL_Get_Max_Threads.
+	plainNames map[string]string
+	// excluded are the routines and constants of the ! lines, which are left out even if other lines select them.
+	excluded map[string]struct{}
+	// groups are the names of the [group name] lines, in the order they first appear.
+	groups []string
+	// lines are the lines of the function list selecting the routines and constants, for the diagnostics.
+	lines map[string]string
+	// unmatched are the entries selecting none or only some of their routines, once the header is read.
+	unmatched []unmatchedEntry
+}
+
+// funcName is the better name of a routine, and the element types it takes and produces.
+type funcName struct {
+	betterName string
+	elem       elemType
+	out        elemType
+	// group is the group of the routine from the [group name] line before its entry, or empty if there is none.
+	group string
+	// renamed is true when the entry names its wrappers with = name.
+	renamed bool
+	// unsafeOnly is true when the entry has unsafe-only, which leaves the routine without the safe wrappers of rust.
+	unsafeOnly bool
+}
+
+// wildcardLetter is what a wildcard is replaced with for the routine taking elem and producing out.
+// out is different from elem only for the mixed precision routines.
+type wildcardLetter struct {
+	elem   elemType
+	out    elemType
+	letter string
+}
+
+// wildcard expands a routine in the function list to the routines on each of its element types.
+type wildcard struct {
+	char    string
+	letters []wildcardLetter
+}
+
+var wildcards = []wildcard{
+	{"*", []wildcardLetter{{float64Elem, float64Elem, "d"}, {float32Elem, float32Elem, "s"}}},
+	{"#", []wildcardLetter{{float64Elem, float64Elem, "D"}, {float32Elem, float32Elem, "S"}}},
+	{"%", []wildcardLetter{{complex128Elem, complex128Elem, "z"}, {complex64Elem, complex64Elem, "c"}}},
+	{"^", []wildcardLetter{{float16Elem, float16Elem, "h"}}},
+	{"@", []wildcardLetter{{bfloat16Elem, bfloat16Elem, "bf16"}}},
+	{"&", []wildcardLetter{
+		{bfloat16Elem, float32Elem, "bf16bf16f32"},
+		{float16Elem, float32Elem, "f16f16f32"},
+		{int16Elem, int32Elem, "s16s16s32"},
+		{int8Elem, int32Elem, "s8u8s32"},
+	}},
+}
+
+// elemNames are the names of the element types in the wildcards file.
+var elemNames = map[string]elemType{
+	"f64":  float64Elem,
+	"f32":  float32Elem,
+	"c64":  complex128Elem,
+	"c32":  complex64Elem,
+	"f16":  float16Elem,
+	"bf16": bfloat16Elem,
+	"i8":   int8Elem,
+	"i16":  int16Elem,
+	"i32":  int32Elem,
 }
 
-func splitName(v string, sep string, s64 string, s32 string) (betterName string, n64 string, n32 string) {
+// wildcardsPath is the file replacing the default wildcards.
+// defaultWildcards are the wildcards without --wildcards, before --wildcard and --upper-wildcard rename them.
+var defaultWildcards = slices.Clone(wildcards)
+
+var wildcardsPath = ""
+
+// readWildcards reads the wildcards from the file at path, one wildcard per line as the wildcard followed by its letters,
+// such as "* d=f64 s=f32". Each letter is what the wildcard is replaced with for the routine on the type,
+// or for the mixed precision routine taking the first type and producing the second, such as bf16bf16f32=bf16:f32.
+func readWildcards(path string) []wildcard {
+	r := []wildcard{}
+	for _, line := range strings.Split(string(readFile(path)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			failf(exitInput, "wildcard %s has no letters", fields[0])
+		}
+		w := wildcard{char: fields[0]}
+		for _, field := range fields[1:] {
+			letter, types, found := strings.Cut(field, "=")
+			if !found {
+				failf(exitInput, "%s of wildcard %s is not letter=type", field, w.char)
+			}
+			in, out, mixed := strings.Cut(types, ":")
+			if !mixed {
+				out = in
+			}
+			elem, knownIn := elemNames[in]
+			outElem, knownOut := elemNames[out]
+			if !knownIn || !knownOut {
+				failf(exitInput, "%s of wildcard %s has an unknown type", field, w.char)
+			}
+			w.letters = append(w.letters, wildcardLetter{elem, outElem, letter})
+		}
+		r = append(r, w)
+	}
+
+	return r
+}
+
+// lowerWildcard and upperWildcard replace the * and # of the default wildcards, for the routines whose names contain them
+// or libraries with other case conventions. An empty one drops the wildcard.
+var (
+	lowerWildcard = "*"
+	upperWildcard = "#"
+)
+
+// renameWildcards replaces the characters of the default * and # wildcards with lowerWildcard and upperWildcard.
+func renameWildcards() {
+	renamed := []wildcard{}
+	for _, w := range wildcards {
+		switch w.char {
+		case "*":
+			w.char = lowerWildcard
+		case "#":
+			w.char = upperWildcard
+		}
+		if w.char != "" {
+			renamed = append(renamed, w)
+		}
+	}
+
+	for i, w := range renamed {
+		for _, other := range renamed[i+1:] {
+			if strings.ContainsAny(w.char, other.char) || strings.ContainsAny(other.char, w.char) {
+				failf(exitInput, "wildcards %s and %s share a character", w.char, other.char)
+			}
+		}
+	}
+
+	wildcards = renamed
+}
+
+// wildcardChars are the characters of all the wildcards.
+func wildcardChars() string {
+	r := ""
+	for _, w := range wildcards {
+		r += w.char
+	}
+
+	return r
+}
+
+func splitName(v string, sep string) (betterName string, prefix string, suffix string) {
 	fixes := strings.Split(v, sep)
 	if len(fixes) != 2 {
-		log.Panicf("%s doesn't containt a valid name", v)
+		failf(exitInput, "%s doesn't containt a valid name", v)
 	}
-	betterName = strings.Join(fixes, "")
-	n64 = fmt.Sprintf("%s%s%s", fixes[0], s64, fixes[1])
-	n32 = fmt.Sprintf("%s%s%s", fixes[0], s32, fixes[1])
-	return
+	prefix, suffix = fixes[0], fixes[1]
+	// cblas_gemm_& is cblas_gemm instead of cblas_gemm_
+	if strings.HasSuffix(prefix, "_") && (suffix == "" || strings.HasPrefix(suffix, "_")) {
+		return strings.TrimSuffix(prefix, "_") + suffix, prefix, suffix
+	}
+	return prefix + suffix, prefix, suffix
 }
 
-func readFuncList(input string) *funcListInput {
-	f := &funcListInput{
-		forFloat64: make(map[string]string),
-		forFloat32: make(map[string]string),
-	}
+// ilp64Suffix is the suffix of the ILP64 symbols, such as cblas_dgemm_64, which take 64-bit integers alongside the LP64 ones.
+const ilp64Suffix = "_64"
 
-	content := ""
+// readInput is the function list in the file at input, or stdin for -, which is converted to the lines if it is in the json format.
+func readInput(input string) string {
+	var content []byte
 	if input == "-" {
-		content = string(getOrPanic(io.ReadAll(os.Stdin)))
+		var err error
+		content, err = io.ReadAll(os.Stdin)
+		orFail(exitInput, err)
 	} else {
-		content = string(getOrPanic(os.ReadFile(input)))
+		content = readFile(input)
+	}
+
+	// a line such as [group blas] is not json
+	if strings.HasPrefix(strings.TrimSpace(string(content)), "[") && json.Valid(content) {
+		return jsonFuncList(input, content)
+	}
+
+	return string(content)
+}
+
+// routineEntry is an entry of the function list in the json format, which is a list of them, such as
+//
+//	[{"pattern": "cblas_*gemm", "rename": "gemm", "group": "blas3"}, {"pattern": "LAPACKE_*potrf", "only": ["f64"], "unsafe_only": true}]
+type routineEntry struct {
+	Pattern    string   `json:"pattern"`
+	Rename     string   `json:"rename"`
+	Group      string   `json:"group"`
+	Only       []string `json:"only"`
+	UnsafeOnly bool     `json:"unsafe_only"`
+}
+
+// jsonFuncList is the lines of the function list in the json format, with [group name] lines between the entries of different groups.
+func jsonFuncList(input string, content []byte) string {
+	var entries []routineEntry
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entries); err != nil {
+		failf(exitInput, "failed to read the function list %s: %v", input, err)
+	}
+
+	lines := []string{}
+	group := ""
+	for _, e := range entries {
+		if e.Pattern == "" {
+			failf(exitInput, "an entry of the function list %s has no pattern", input)
+		}
+		switch {
+		case e.Group == group:
+		case e.Group == "":
+			lines = append(lines, "[group]")
+		default:
+			lines = append(lines, fmt.Sprintf("[group %s]", e.Group))
+		}
+		group = e.Group
+		line := e.Pattern
+		if e.Rename != "" {
+			line += " = " + e.Rename
+		}
+		if len(e.Only) > 0 {
+			line += " only:" + strings.Join(e.Only, ",")
+		}
+		if e.UnsafeOnly {
+			line += " unsafe-only"
+		}
+		lines = append(lines, line)
+	}
+	// the lists of the other inputs are not in the group of the last entry
+	if group != "" {
+		lines = append(lines, "[group]")
 	}
 
+	return strings.Join(lines, "\n")
+}
+
+func readFuncList(content string) *funcListInput {
+	f := &funcListInput{
+		names:      make(map[string]funcName),
+		plainNames: make(map[string]string),
+		excluded:   make(map[string]struct{}),
+		lines:      make(map[string]string),
+	}
+
+	excludes := []string{}
+	group := ""
 	for _, inputline := range strings.Split(content, "\n") {
 		v := strings.TrimRight(strings.TrimLeft(inputline, " "), " ")
 		if v == "" {
 			continue
 		}
 
+		// [group blas] puts the routines of the lines after it in group blas, until the next [group name] line,
+		// and [group] puts them back in no group.
+		if name, isGroup := parseGroupLine(v); isGroup {
+			group = name
+			if name != "" && !slices.Contains(f.groups, name) {
+				f.groups = append(f.groups, name)
+			}
+			f.desiredFuncList = append(f.desiredFuncList, v)
+			continue
+		}
+
+		// the lists merged from several inputs may repeat entries
+		if slices.Contains(f.desiredFuncList, v) {
+			continue
+		}
+
 		f.desiredFuncList = append(f.desiredFuncList, v)
 
-		var bn, n64, n32 string
-		if strings.Contains(v, "*") {
-			bn, n64, n32 = splitName(v, "*", "d", "s")
-		} else if strings.Contains(v, "#") {
-			bn, n64, n32 = splitName(v, "#", "D", "S")
+		// !cblas_*gemm_batch leaves out the routines of cblas_*gemm_batch, which is applied after all the other lines.
+		line := v
+		v, isExclude := strings.CutPrefix(v, "!")
+
+		// LAPACKE_*potrf = cholesky_factor names the wrappers cholesky_factor instead of LAPACKE_potrf,
+		// and the options follow the entry, or the new name if it is renamed, such as LAPACKE_*potrf only:f64.
+		v, rename, hasRename := strings.Cut(v, "=")
+		fields := strings.Fields(v)
+		if hasRename {
+			if len(fields) != 1 {
+				failf(exitInput, "%s should be the entry, the new name, then the options", line)
+			}
+			fields = strings.Fields(rename)
+		}
+		if len(fields) == 0 {
+			failf(exitInput, "%s has no name", line)
+		}
+		opts := parseLineOptions(line, fields[1:])
+		if hasRename {
+			rename = fields[0]
+		}
+		// group:lapack puts the routines of the entry in group lapack, whatever the [group name] line before it
+		entryGroup := group
+		if opts.group != "" {
+			entryGroup = opts.group
+			if !slices.Contains(f.groups, opts.group) {
+				f.groups = append(f.groups, opts.group)
+			}
+		}
+		v = strings.TrimSpace(strings.Fields(v)[0])
+		if provider := selectedProvider(); provider != nil {
+			v = provider.entryName(v)
+		}
+
+		if isExclude {
+			if hasRename || len(fields) > 1 {
+				failf(exitInput, "%s leaves out the routines, which take neither new names nor options", line)
+			}
+			excludes = append(excludes, v)
+			continue
+		}
+
+		// a name without wildcards is a routine generated as a plain function, such as mkl_get_max_threads,
+		// or otherwise a constant, such as VML_HA, which is decided once the header is read.
+		if !strings.ContainsAny(v, wildcardChars()) {
+			if opts.only != nil {
+				failf(exitInput, "%s has no wildcard, and has no types to select", v)
+			}
+			bn := v
+			if hasRename {
+				bn = rename
+			}
+			f.selectName(v, line)
+			f.names[v] = funcName{betterName: bn, elem: noElem, out: noElem, group: entryGroup, unsafeOnly: opts.unsafeOnly}
+			f.plainNames[v] = v
+			f.constNames = append(f.constNames, v)
+			continue
 		}
 
-		f.forFloat64[n64] = bn
-		f.forFloat32[n32] = bn
+		for name, n := range expandName(v) {
+			if opts.only != nil && !slices.Contains(opts.only, n.elem) {
+				continue
+			}
+			if provider := selectedProvider(); hasRename {
+				n.betterName, n.renamed = rename, true
+			} else if provider != nil {
+				n.betterName = provider.wrapperName(n.betterName)
+			}
+			n.group, n.unsafeOnly = entryGroup, opts.unsafeOnly
+			f.selectName(name, line)
+			f.names[name] = n
+		}
+	}
 
+	for _, v := range excludes {
+		f.exclude(v)
 	}
 
 	return f
 }
 
-func (f *funcListInput) findFunc(funcName string) (is32 bool, is64 bool, betterName string) {
-	betterName, is32 = f.forFloat32[funcName]
-	if is32 {
-		is64 = false
-		return
+// selectName records that line selects routine or constant name, which fails if another line selects it too.
+func (f *funcListInput) selectName(name string, line string) {
+	if prev, found := f.lines[name]; found {
+		failf(exitInput, "%s is selected by both %s and %s, which should be one entry", name, prev, line)
+	}
+	f.lines[name] = line
+}
+
+// lineOf is the line of the function list selecting routine name, for the diagnostics.
+func (f *funcListInput) lineOf(name string) string {
+	if line, found := f.lines[name]; found {
+		return line
+	}
+
+	return "(not in the function list)"
+}
+
+// logUndeclared logs the routines of the function list that are not declared in the header, with -vv,
+// such as the single precision routine of an entry when the header only has the double precision one.
+func (f *funcListInput) logUndeclared(declared map[string]struct{}) {
+	names := make([]string, 0)
+	for name := range f.names {
+		// the names without wildcards that are not routines are constants, which are warned about if they are neither
+		_, found := declared[name]
+		_, isPlain := f.plainNames[name]
+		if !found && !isPlain {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		debugf("skipped %s of %s, which is not declared as a function in the header", name, f.lineOf(name))
+	}
+}
+
+// checkDuplicates fails if routines of the same types in the same group have the same better name,
+// which would be generated as the same trait method or function twice.
+func (f *funcListInput) checkDuplicates(funcs []funcDef) {
+	type wrapper struct {
+		group string
+		name  string
+		elem  elemType
+		out   elemType
+	}
+
+	seen := make(map[wrapper]string)
+	duplicates := []string{}
+	for _, fn := range funcs {
+		w := wrapper{group: fn.group, name: fn.BetterName, elem: fn.elem, out: fn.out}
+		if prev, found := seen[w]; found {
+			duplicates = append(duplicates, fmt.Sprintf("%s from %s and %s from %s are both %s", prev, f.lineOf(prev), fn.RawName, f.lineOf(fn.RawName), fn.BetterName))
+			continue
+		}
+		seen[w] = fn.RawName
+	}
+
+	if len(duplicates) > 0 {
+		failf(exitInput, "wrappers have the same names, rename the entries with = name: %s", strings.Join(duplicates, "; "))
+	}
+}
+
+// parseGroupLine is the name of the group of a [group name] line, which is empty for [group], and whether v is such a line.
+func parseGroupLine(v string) (string, bool) {
+	inner, isSection := strings.CutPrefix(v, "[")
+	if !isSection {
+		return "", false
+	}
+
+	inner, closed := strings.CutSuffix(inner, "]")
+	fields := strings.Fields(inner)
+	if !closed || len(fields) == 0 || len(fields) > 2 || fields[0] != "group" {
+		failf(exitInput, "%s should be [group name], or [group] for no group", v)
+	}
+	if len(fields) == 1 {
+		return "", true
+	}
+
+	checkGroupName(fields[1], v)
+
+	return fields[1], true
+}
+
+// checkGroupName fails unless group name of line v is an identifier, as the group is the name of a rust module, a go file and a c++ namespace.
+func checkGroupName(name string, v string) {
+	for i, c := range name {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			failf(exitInput, "group %s of %s should be an identifier", name, v)
+		}
+	}
+}
+
+// onlyGroup is the group of groups if there is only one, or no group, for the routines added for the routines of groups.
+func onlyGroup(groups map[string]struct{}) string {
+	if len(groups) != 1 {
+		return ""
+	}
+	for group := range groups {
+		return group
+	}
+
+	return ""
+}
+
+// lineOptions are the options of an entry of the function list, which follow the entry.
+type lineOptions struct {
+	// only are the types of the routines to select, from only:f64 or only:f64,c64, instead of all the letters of the wildcard.
+	only []elemType
+	// unsafeOnly leaves the routines without the safe wrappers of rust, from unsafe-only.
+	unsafeOnly bool
+	// group is the group of the routines from group:name, instead of the group of the [group name] line before the entry.
+	group string
+}
+
+// parseLineOptions parses the options of entry v.
+func parseLineOptions(v string, fields []string) lineOptions {
+	r := lineOptions{}
+	for _, field := range fields {
+		key, value, _ := strings.Cut(field, ":")
+		switch {
+		case key == "unsafe-only" && field == key:
+			r.unsafeOnly = true
+		case key == "group" && value != "":
+			checkGroupName(value, v)
+			r.group = value
+		case key == "only":
+			for _, name := range strings.Split(value, ",") {
+				elem, known := elemNames[name]
+				if !known {
+					failf(exitInput, "%s of %s has an unknown type", field, v)
+				}
+				r.only = append(r.only, elem)
+			}
+		default:
+			failf(exitInput, "%s of %s is not an option, the options are only:<types>, unsafe-only and group:<name>", field, v)
+		}
 	}
-	betterName, is64 = f.forFloat64[funcName]
-	if is64 {
-		is32 = false
+
+	return r
+}
+
+// exclude removes the routines of entry v of the function list, or the routine or constant v without wildcards.
+func (f *funcListInput) exclude(v string) {
+	if !strings.ContainsAny(v, wildcardChars()) {
+		f.excluded[v] = struct{}{}
+		delete(f.names, v)
+		delete(f.plainNames, v)
+		f.constNames = slices.DeleteFunc(f.constNames, func(c string) bool { return c == v })
 		return
 	}
 
+	for name := range expandName(v) {
+		f.excluded[name] = struct{}{}
+		delete(f.names, name)
+	}
+}
+
+// isExcluded is true if the routine is left out by a ! line.
+func (f *funcListInput) isExcluded(name string) bool {
+	_, excluded := f.excluded[name]
+	return excluded
+}
+
+// expandName is the routines of entry v of the function list, replacing its wildcard with each of the letters.
+func expandName(v string) map[string]funcName {
+	r := make(map[string]funcName)
+	for _, w := range wildcards {
+		if !strings.Contains(v, w.char) {
+			continue
+		}
+		bn, prefix, suffix := splitName(v, w.char)
+		// with --fortran-symbols, *gemm and *gemm_ are both gemm calling dgemm_ and sgemm_,
+		// and the fortran suffix comes after the ilp64 one.
+		if fortranSymbols {
+			bn = strings.TrimSuffix(bn, fortranSymbolSuffix)
+			suffix = strings.TrimSuffix(suffix, fortranSymbolSuffix)
+		}
+		// cblas_*gemm_64 is cblas_gemm, and with --ilp64-symbols cblas_*gemm is cblas_dgemm_64 and cblas_sgemm_64.
+		bn = strings.TrimSuffix(bn, ilp64Suffix)
+		if ilp64Symbols && !strings.HasSuffix(suffix, ilp64Suffix) {
+			suffix = suffix + ilp64Suffix
+		}
+		if fortranSymbols {
+			suffix = suffix + fortranSymbolSuffix
+		}
+		for _, l := range w.letters {
+			r[fmt.Sprintf("%s%s%s", prefix, l.letter, suffix)] = funcName{betterName: bn, elem: l.elem, out: l.out}
+		}
+		break
+	}
+
+	return r
+}
+
+func (f *funcListInput) findFunc(name string) (fn funcName, found bool) {
+	fn, found = f.names[name]
 	return
 }
+
+// resolvePlainMacros calls the routines the entries without wildcards are macros of, such as MKL_Get_Max_Threads
+// for mkl_get_max_threads, keeping the names of the entries.
+func (f *funcListInput) resolvePlainMacros(ast *cc.AST) {
+	names := make([]string, 0, len(f.plainNames))
+	for name := range f.plainNames {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		m, isMacro := ast.Macros[name]
+		if !isMacro || m.IsFnLike {
+			continue
+		}
+		r := m.ReplacementList()
+		if len(r) != 1 || r[0].Ch != rune(cc.IDENTIFIER) {
+			continue
+		}
+		target := r[0].SrcStr()
+		if _, found := f.names[target]; found {
+			continue
+		}
+		f.names[target] = f.names[name]
+		f.plainNames[target] = f.plainNames[name]
+		f.lines[target] = f.lines[name]
+		delete(f.names, name)
+		delete(f.plainNames, name)
+	}
+}
+
+// dropRoutineConstants removes the entries without wildcards that are routines from the constants.
+func (f *funcListInput) dropRoutineConstants(funcs []funcDef) {
+	for _, fn := range funcs {
+		if entry, isPlain := f.plainNames[fn.RawName]; isPlain {
+			f.constNames = slices.DeleteFunc(f.constNames, func(c string) bool { return c == entry })
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestLineOptionGroup(t *testing.T) {
+	f := readFuncList(`[group blas]
+cblas_*gemm
+LAPACKE_*potrf group:lapack
+LAPACKE_*getrf = lu only:f64 group:lapack
+cblas_*axpy
+[group]
+mkl_get_max_threads group:service
+vdAdd
+`)
+
+	groups := map[string]string{
+		"cblas_dgemm":         "blas",
+		"cblas_sgemm":         "blas",
+		"LAPACKE_dpotrf":      "lapack",
+		"LAPACKE_spotrf":      "lapack",
+		"LAPACKE_dgetrf":      "lapack",
+		"cblas_daxpy":         "blas",
+		"mkl_get_max_threads": "service",
+		"vdAdd":               "",
+	}
+	for name, want := range groups {
+		n, found := f.names[name]
+		if !found {
+			t.Errorf("%s is not selected", name)
+			continue
+		}
+		if n.group != want {
+			t.Errorf("%s is in group %q, want %q", name, n.group, want)
+		}
+	}
+	if n := f.names["LAPACKE_dgetrf"]; n.betterName != "lu" || !n.renamed {
+		t.Errorf("LAPACKE_dgetrf is generated as %s, want lu", n.betterName)
+	}
+	if _, found := f.names["LAPACKE_sgetrf"]; found {
+		t.Error("LAPACKE_sgetrf is selected by only:f64")
+	}
+	if want := []string{"blas", "lapack", "service"}; len(f.groups) != len(want) || f.groups[0] != want[0] || f.groups[1] != want[1] || f.groups[2] != want[2] {
+		t.Errorf("the groups are %v, want %v", f.groups, want)
+	}
+}
+
+func TestLineOptionUnsafeOnly(t *testing.T) {
+	// the entries of the json format take unsafe_only
+	f := readFuncList(jsonFuncList("list.json", []byte(`[{"pattern": "LAPACKE_*potrf", "unsafe_only": true}, {"pattern": "LAPACKE_*getrf"}]`)) +
+		"\ncblas_*scal unsafe-only\ncblas_*dot\nmkl_free_buffers unsafe-only\n")
+
+	for name, want := range map[string]bool{
+		"cblas_dscal": true, "cblas_sscal": true, "mkl_free_buffers": true, "LAPACKE_dpotrf": true,
+		"cblas_ddot": false, "LAPACKE_sgetrf": false,
+	} {
+		if got := f.names[name].unsafeOnly; got != want {
+			t.Errorf("%s is unsafe-only %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// funcPointer is the function type of a function pointer parameter, such as a callback.
+type funcPointer struct {
+	returnType string
+	args       []funcArg
+}
+
+// funcPointerTypes are the function pointer types of the parameters, by their c type names.
+// The type maps of the backends look up the function types here.
+var funcPointerTypes = make(map[string]*funcPointer)
+
+// cType is the c type name of the function pointer, for example int (*)(const double *, int).
+func (f *funcPointer) cType() string {
+	return f.declare("")
+}
+
+// declare is the declaration of the function pointer called name.
+func (f *funcPointer) declare(name string) string {
+	args := []string{}
+	for _, arg := range f.args {
+		args = append(args, arg.declType)
+	}
+	if len(args) == 0 {
+		args = append(args, "void")
+	}
+
+	return fmt.Sprintf("%s (*%s)(%s)", f.returnType, name, strings.Join(args, ", "))
+}
+
+// newFuncPointer records the function pointer type returning returnType with the parameters of ft.
+func newFuncPointer(returnType string, ft *cc.FunctionType) *funcPointer {
+	f := &funcPointer{returnType: returnType, args: retrieveParams(ft)}
+	funcPointerTypes[f.cType()] = f
+
+	return f
+}
+
+// funcPointerOf is the function pointer of type t, such as int (*cb)(const double *, int), with the pointers of its return declared by p.
+// It is nil if t is not a pointer to a function, or is named by a typedef, such as the callbacks of the header, which are kept as named.
+func funcPointerOf(t cc.Type, p *cc.Pointer) *funcPointer {
+	ft := funcPointee(t)
+	if ft == nil {
+		return nil
+	}
+
+	return newFuncPointer(cTypeName(ft.Result(), p, true), ft)
+}
+
+// funcPointee is the function type t points to, or nil if t is not a pointer to a function, or is named by a typedef.
+func funcPointee(t cc.Type) *cc.FunctionType {
+	pt, isPointer := t.(*cc.PointerType)
+	if !isPointer || t.Typedef() != nil {
+		return nil
+	}
+	ft, _ := pt.Elem().(*cc.FunctionType)
+
+	return ft
+}
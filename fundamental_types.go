@@ -0,0 +1,349 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// targetABI is the abi the header is translated for, which decides the sizes of long and the like.
+var targetABI *cc.ABI
+
+// fundamentalType is the type the backends know about for the arithmetic type t.
+// Enums, structs and pointers, such as the CBLAS enums, MKL_Complex16 and the VSL stream handles, are kept as named.
+func fundamentalType(t cc.Type) (string, bool) {
+	return fundamentalKind(t.Kind(), t.Size())
+}
+
+// fundamentalKind is the type the backends know about for the arithmetic type of kind k, which is size bytes.
+func fundamentalKind(k cc.Kind, size int64) (string, bool) {
+	switch k {
+	case cc.Char:
+		return "char", true
+	case cc.Float:
+		return "float", true
+	case cc.Double:
+		return "double", true
+	// the c99 complex types of the headers other than mkl, such as lapack_complex_float of lapacke.h,
+	// are laid out as the complex types of mkl, which the backends know about
+	case cc.ComplexFloat:
+		return "MKL_Complex8", true
+	case cc.ComplexDouble:
+		return "MKL_Complex16", true
+	case cc.Int, cc.Long, cc.LongLong:
+		if size == 8 {
+			return "int64_t", true
+		}
+		return "int", true
+	case cc.ULong, cc.ULongLong:
+		if size == 8 {
+			return "uint64_t", true
+		}
+		return "unsigned int", true
+	}
+
+	return "", false
+}
+
+// integerType is an integer type in each of the outputs.
+type integerType struct {
+	goType, rustType, juliaType, zigType string
+	// pyType is the ctypes type, and nodeType the koffi type.
+	pyType, nodeType string
+	// ocamlType is the ctypes type, and ocamlValue the type of its values in ocaml.
+	ocamlType, ocamlValue                string
+	pascalType, crystalType, haskellType string
+	// javaType is the primitive type of java, and javaLayout the layout of the linker.
+	javaType, javaLayout string
+	adaType              string
+	// fortranKind is the iso_c_binding kind, signed as fortran has no unsigned integers.
+	fortranKind           string
+	swiftType, kotlinType string
+	// rArg is the conversion of the SEXP of the argument in the c shim of r, and rResult the SEXP of the value returned.
+	rArg, rResult string
+}
+
+// integerTypes are the integer types the typedefs collapse to in the outputs, such as int64_t of MKL_INT with --ilp64.
+var integerTypes = map[string]integerType{
+	"int": {
+		goType:      "int32",
+		rustType:    "i32",
+		juliaType:   "Cint",
+		zigType:     "c_int",
+		pyType:      "c_int",
+		nodeType:    "int",
+		ocamlType:   "int",
+		ocamlValue:  "int",
+		pascalType:  "LongInt",
+		crystalType: "Int32",
+		haskellType: "CInt",
+		javaType:    "int",
+		javaLayout:  "JAVA_INT",
+		adaType:     "Interfaces.C.int",
+		fortranKind: "c_int",
+		swiftType:   "Int32",
+		kotlinType:  "Int",
+		rArg:        "Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger(%s)",
+	},
+	"int32_t": {
+		goType:      "int32",
+		rustType:    "i32",
+		juliaType:   "Cint",
+		zigType:     "c_int",
+		pyType:      "c_int",
+		nodeType:    "int",
+		ocamlType:   "int",
+		ocamlValue:  "int",
+		pascalType:  "LongInt",
+		crystalType: "Int32",
+		haskellType: "CInt",
+		javaType:    "int",
+		javaLayout:  "JAVA_INT",
+		adaType:     "Interfaces.C.int",
+		fortranKind: "c_int",
+		swiftType:   "Int32",
+		kotlinType:  "Int",
+		rArg:        "Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger(%s)",
+	},
+	"int64_t": {
+		goType:      "int64",
+		rustType:    "i64",
+		juliaType:   "Int64",
+		zigType:     "i64",
+		pyType:      "c_int64",
+		nodeType:    "int64_t",
+		ocamlType:   "int64_t",
+		ocamlValue:  "int64",
+		pascalType:  "Int64",
+		crystalType: "Int64",
+		haskellType: "Int64",
+		javaType:    "long",
+		javaLayout:  "JAVA_LONG",
+		adaType:     "Interfaces.Integer_64",
+		fortranKind: "c_int64_t",
+		swiftType:   "Int64",
+		kotlinType:  "Long",
+		rArg:        "(int64_t)Rf_asReal(%s)",
+		rResult:     "Rf_ScalarReal((double)%s)",
+	},
+	"uint64_t": {
+		goType:      "uint64",
+		rustType:    "u64",
+		juliaType:   "UInt64",
+		zigType:     "u64",
+		pyType:      "c_uint64",
+		nodeType:    "uint64_t",
+		ocamlType:   "uint64_t",
+		ocamlValue:  "Unsigned.uint64",
+		pascalType:  "UInt64",
+		crystalType: "UInt64",
+		haskellType: "Word64",
+		javaType:    "long",
+		javaLayout:  "JAVA_LONG",
+		adaType:     "Interfaces.Unsigned_64",
+		fortranKind: "c_int64_t",
+		swiftType:   "UInt64",
+		kotlinType:  "ULong",
+		rArg:        "(uint64_t)Rf_asReal(%s)",
+		rResult:     "Rf_ScalarReal((double)%s)",
+	},
+	"size_t": {
+		goType:      "uint64",
+		rustType:    "usize",
+		juliaType:   "Csize_t",
+		zigType:     "usize",
+		pyType:      "c_size_t",
+		nodeType:    "size_t",
+		ocamlType:   "size_t",
+		ocamlValue:  "Unsigned.size_t",
+		pascalType:  "NativeUInt",
+		crystalType: "LibC::SizeT",
+		haskellType: "CSize",
+		javaType:    "long",
+		javaLayout:  "JAVA_LONG",
+		adaType:     "Interfaces.C.size_t",
+		fortranKind: "c_size_t",
+		swiftType:   "Int",
+		kotlinType:  "ULong",
+		rArg:        "(size_t)Rf_asReal(%s)",
+		rResult:     "Rf_ScalarReal((double)%s)",
+	},
+	"unsigned int": {
+		goType:      "uint32",
+		rustType:    "u32",
+		juliaType:   "Cuint",
+		zigType:     "c_uint",
+		pyType:      "c_uint",
+		nodeType:    "unsigned int",
+		ocamlType:   "uint",
+		ocamlValue:  "Unsigned.uint",
+		pascalType:  "Cardinal",
+		crystalType: "UInt32",
+		haskellType: "CUInt",
+		javaType:    "int",
+		javaLayout:  "JAVA_INT",
+		adaType:     "Interfaces.C.unsigned",
+		fortranKind: "c_int",
+		swiftType:   "UInt32",
+		kotlinType:  "UInt",
+		rArg:        "(unsigned int)Rf_asReal(%s)",
+		rResult:     "Rf_ScalarReal((double)%s)",
+	},
+	"signed char": {
+		goType:      "int8",
+		rustType:    "i8",
+		juliaType:   "Int8",
+		zigType:     "i8",
+		pyType:      "c_byte",
+		nodeType:    "int8_t",
+		ocamlType:   "schar",
+		ocamlValue:  "int",
+		pascalType:  "ShortInt",
+		crystalType: "Int8",
+		haskellType: "CSChar",
+		javaType:    "byte",
+		javaLayout:  "JAVA_BYTE",
+		adaType:     "Interfaces.C.signed_char",
+		fortranKind: "c_signed_char",
+		swiftType:   "Int8",
+		kotlinType:  "Byte",
+		rArg:        "(signed char)Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger((int)%s)",
+	},
+	"unsigned char": {
+		goType:      "uint8",
+		rustType:    "u8",
+		juliaType:   "Cuchar",
+		zigType:     "u8",
+		pyType:      "c_ubyte",
+		nodeType:    "uint8_t",
+		ocamlType:   "uchar",
+		ocamlValue:  "Unsigned.uchar",
+		pascalType:  "Byte",
+		crystalType: "UInt8",
+		haskellType: "CUChar",
+		javaType:    "byte",
+		javaLayout:  "JAVA_BYTE",
+		adaType:     "Interfaces.C.unsigned_char",
+		fortranKind: "c_signed_char",
+		swiftType:   "UInt8",
+		kotlinType:  "UByte",
+		rArg:        "(unsigned char)Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger((int)%s)",
+	},
+	"short": {
+		goType:      "int16",
+		rustType:    "i16",
+		juliaType:   "Cshort",
+		zigType:     "c_short",
+		pyType:      "c_short",
+		nodeType:    "int16_t",
+		ocamlType:   "short",
+		ocamlValue:  "int",
+		pascalType:  "SmallInt",
+		crystalType: "Int16",
+		haskellType: "CShort",
+		javaType:    "short",
+		javaLayout:  "JAVA_SHORT",
+		adaType:     "Interfaces.C.short",
+		fortranKind: "c_short",
+		swiftType:   "Int16",
+		kotlinType:  "Short",
+		rArg:        "(short)Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger((int)%s)",
+	},
+	"unsigned short": {
+		goType:      "uint16",
+		rustType:    "u16",
+		juliaType:   "Cushort",
+		zigType:     "c_ushort",
+		pyType:      "c_ushort",
+		nodeType:    "uint16_t",
+		ocamlType:   "ushort",
+		ocamlValue:  "Unsigned.ushort",
+		pascalType:  "Word",
+		crystalType: "UInt16",
+		haskellType: "CUShort",
+		javaType:    "short",
+		javaLayout:  "JAVA_SHORT",
+		adaType:     "Interfaces.C.unsigned_short",
+		fortranKind: "c_short",
+		swiftType:   "UInt16",
+		kotlinType:  "UShort",
+		rArg:        "(unsigned short)Rf_asInteger(%s)",
+		rResult:     "Rf_ScalarInteger((int)%s)",
+	},
+}
+
+// lookupInteger is the integer type of c type t, which may be const, such as const MKL_INT.
+func lookupInteger(t string) (integerType, bool) {
+	integer, isInteger := integerTypes[strings.TrimPrefix(t, "const ")]
+	return integer, isInteger
+}
+
+// complexTypedefs are the complex types of the blases other than mkl, such as cuComplex of cublas, which are structs of the real
+// and imaginary parts laid out as the complex types of mkl, so they are taken as them instead of as structs.
+var complexTypedefs = map[string]string{
+	"cuComplex":              "MKL_Complex8",
+	"cuFloatComplex":         "MKL_Complex8",
+	"cuDoubleComplex":        "MKL_Complex16",
+	"rocblas_float_complex":  "MKL_Complex8",
+	"rocblas_double_complex": "MKL_Complex16",
+	"hipblasComplex":         "MKL_Complex8",
+	"hipblasDoubleComplex":   "MKL_Complex16",
+	"hipComplex":             "MKL_Complex8",
+	"hipFloatComplex":        "MKL_Complex8",
+	"hipDoubleComplex":       "MKL_Complex16",
+	"scomplex":               "MKL_Complex8",
+	"dcomplex":               "MKL_Complex16",
+	"fftwf_complex":          "MKL_Complex8",
+	"fftw_complex":           "MKL_Complex16",
+}
+
+// precisionTypedefs are the types other than the float and complex ones that differ by the precision of the routines,
+// such as fftwf_plan and fftw_plan of fftwf_execute and fftw_execute, by the name they share in the traits of rust,
+// which declare them as associated types.
+var precisionTypedefs = map[string]string{
+	"fftwf_plan": "fftw_plan",
+	"fftw_plan":  "fftw_plan",
+}
+
+// precisionTypedef is the name in the traits of t, if it is a type of precisionTypedefs or a const one, such as const fftw_plan.
+func precisionTypedef(t string) (string, bool) {
+	name, found := precisionTypedefs[strings.TrimPrefix(t, "const ")]
+	return name, found
+}
+
+// complexTypedefNames are the names of complexTypedefs, sorted.
+func complexTypedefNames() []string {
+	r := make([]string, 0, len(complexTypedefs))
+	for name := range complexTypedefs {
+		r = append(r, name)
+	}
+	sort.Strings(r)
+
+	return r
+}
+
+// arithmeticKinds are the kinds of the canonical spellings of the arithmetic types.
+var arithmeticKinds = map[string]cc.Kind{
+	"char":               cc.Char,
+	"signed char":        cc.SChar,
+	"unsigned char":      cc.UChar,
+	"short":              cc.Short,
+	"unsigned short":     cc.UShort,
+	"int":                cc.Int,
+	"unsigned int":       cc.UInt,
+	"long":               cc.Long,
+	"unsigned long":      cc.ULong,
+	"long long":          cc.LongLong,
+	"unsigned long long": cc.ULongLong,
+	"float":              cc.Float,
+	"double":             cc.Double,
+	"long double":        cc.LongDouble,
+	"float _Complex":     cc.ComplexFloat,
+	"double _Complex":    cc.ComplexDouble,
+}
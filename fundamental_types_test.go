@@ -0,0 +1,301 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// selectFromHeader is the routines of the function list list selected from header, written to mkl.h of a temporary directory,
+// by their names in the header.
+func selectFromHeader(t *testing.T, header string, list string) map[string]funcDef {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mkl.h")
+	if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previousPath, previousCache := mklPath, headerCache
+	mklPath, headerCache = path, false
+	t.Cleanup(func() {
+		mklPath, headerCache = previousPath, previousCache
+		resetRoutineState()
+	})
+
+	_, funcs := selectRoutines(translateHeader(list), list)
+	byName := make(map[string]funcDef)
+	for _, f := range funcs {
+		byName[f.RawName] = f
+	}
+
+	return byName
+}
+
+// the typedefs of mkl_types.h and mkl_lapacke.h, and routines taking them
+const typedefHeader = `
+typedef int MKL_INT;
+typedef long long MKL_INT64;
+typedef unsigned long long MKL_UINT64;
+typedef unsigned long size_t;
+typedef MKL_INT lapack_int;
+#define CBLAS_INDEX size_t
+CBLAS_INDEX cblas_idamax(const MKL_INT N, const double *X, const MKL_INT incX);
+CBLAS_INDEX cblas_isamax(const MKL_INT N, const float *X, const MKL_INT incX);
+lapack_int LAPACKE_dpotrf(int matrix_layout, char uplo, lapack_int n, double *a, lapack_int lda);
+lapack_int LAPACKE_spotrf(int matrix_layout, char uplo, lapack_int n, float *a, lapack_int lda);
+MKL_INT64 mkl_dtest(const MKL_UINT64 seed, MKL_INT64 *count, const double *x);
+MKL_INT64 mkl_stest(const MKL_UINT64 seed, MKL_INT64 *count, const float *x);
+`
+
+const typedefList = `cblas_i*amax
+LAPACKE_*potrf
+mkl_*test
+`
+
+func TestTypedefsResolved(t *testing.T) {
+	funcs := selectFromHeader(t, typedefHeader, typedefList)
+
+	tests := []struct {
+		routine string
+		param   int
+		decl    string
+		c       string
+		rust    string
+		goType  string
+	}{
+		{"cblas_idamax", 0, "const MKL_INT", "const int", "i32", "int32"},
+		{"LAPACKE_spotrf", 2, "lapack_int", "int", "i32", "int32"},
+		{"mkl_dtest", 0, "const MKL_UINT64", "const uint64_t", "u64", "uint64"},
+		{"mkl_stest", 1, "MKL_INT64 *", "int64_t *", "*mut i64", "*int64"},
+	}
+	for _, test := range tests {
+		f, found := funcs[test.routine]
+		if !found {
+			t.Fatalf("%s is not selected", test.routine)
+		}
+		p := f.args[test.param]
+		if p.declType != test.decl {
+			t.Errorf("parameter %d of %s is declared as %q, want %q", test.param, test.routine, p.declType, test.decl)
+		}
+		if p.typeName != test.c {
+			t.Errorf("parameter %d of %s is %q, want %q", test.param, test.routine, p.typeName, test.c)
+		}
+		if rust, _ := getRustParamType(p.typeName); rust != test.rust {
+			t.Errorf("parameter %d of %s is %q in rust, want %q", test.param, test.routine, rust, test.rust)
+		}
+		if goType := getGoParamType(p.typeName); goType != test.goType {
+			t.Errorf("parameter %d of %s is %q in go, want %q", test.param, test.routine, goType, test.goType)
+		}
+	}
+
+	returns := map[string]string{
+		"cblas_isamax":   "size_t",
+		"LAPACKE_dpotrf": "int",
+		"mkl_dtest":      "int64_t",
+	}
+	for routine, want := range returns {
+		if got := funcs[routine].ReturnType; got != want {
+			t.Errorf("%s returns %q, want %q", routine, got, want)
+		}
+	}
+}
+
+// the types of the service functions spelled with several keywords, on routines taking the element types
+const multiTokenHeader = `
+#define MKL_INT64 long long int
+void mkl_dtest_clocks(unsigned MKL_INT64 *clocks, double *x);
+void mkl_stest_clocks(unsigned MKL_INT64 *clocks, float *x);
+unsigned long long mkl_dtest_usage(long long int mode, const signed char *name, double x);
+unsigned long long mkl_stest_usage(long long int mode, const signed char *name, float x);
+long int mkl_dtest_short(short a, unsigned short *b, unsigned char c, double x);
+long int mkl_stest_short(short a, unsigned short *b, unsigned char c, float x);
+`
+
+const multiTokenList = `mkl_*test_clocks
+mkl_*test_usage
+mkl_*test_short
+`
+
+func TestMultiTokenSpecifiers(t *testing.T) {
+	funcs := selectFromHeader(t, multiTokenHeader, multiTokenList)
+
+	tests := []struct {
+		routine string
+		param   int
+		decl    string
+		c       string
+	}{
+		{"mkl_dtest_clocks", 0, "unsigned long long *", "uint64_t *"},
+		{"mkl_dtest_usage", 0, "long long", "int64_t"},
+		{"mkl_dtest_usage", 1, "const signed char *", "const signed char *"},
+		{"mkl_stest_short", 0, "short", "short"},
+		{"mkl_stest_short", 1, "unsigned short *", "unsigned short *"},
+		{"mkl_stest_short", 2, "unsigned char", "unsigned char"},
+	}
+	for _, test := range tests {
+		f, found := funcs[test.routine]
+		if !found {
+			t.Fatalf("%s is not selected", test.routine)
+		}
+		p := f.args[test.param]
+		if p.declType != test.decl {
+			t.Errorf("parameter %d of %s is declared as %q, want %q", test.param, test.routine, p.declType, test.decl)
+		}
+		if p.typeName != test.c {
+			t.Errorf("parameter %d of %s is %q, want %q", test.param, test.routine, p.typeName, test.c)
+		}
+	}
+
+	returns := map[string]string{
+		"mkl_dtest_usage": "uint64_t",
+		"mkl_stest_short": "int64_t",
+	}
+	for routine, want := range returns {
+		if got := funcs[routine].ReturnType; got != want {
+			t.Errorf("%s returns %q, want %q", routine, got, want)
+		}
+	}
+	if got, _ := getRustParamType(funcs["mkl_stest_clocks"].args[0].typeName); got != "*mut u64" {
+		t.Errorf("unsigned long long * is %q in rust, want *mut u64", got)
+	}
+	if got := getGoParamType(funcs["mkl_dtest_usage"].args[0].typeName); got != "int64" {
+		t.Errorf("long long is %q in go, want int64", got)
+	}
+}
+
+// the service functions, and the ones taking the fundamental types spelled with several keywords
+const serviceHeader = `
+#define MKL_INT64 long long int
+typedef unsigned long long MKL_UINT64;
+typedef unsigned long size_t;
+void mkl_get_cpu_clocks(unsigned MKL_INT64 *clocks);
+MKL_UINT64 mkl_peak_mem_usage(int mode);
+void *mkl_malloc(size_t alloc_size, int alignment);
+int mkl_set_num_threads_local(int nth);
+MKL_INT64 mkl_mem_stat(int *AllocatedBuffers);
+void mkl_get_version_string(char *buf, int len);
+signed char mkl_test_schar(signed char a, unsigned char *b);
+unsigned short mkl_test_ushort(short a, unsigned short *b, const signed char *c);
+`
+
+const serviceList = `mkl_set_num_threads_local
+mkl_get_cpu_clocks
+mkl_peak_mem_usage
+mkl_malloc
+mkl_mem_stat
+mkl_get_version_string
+mkl_test_schar
+mkl_test_ushort
+`
+
+func TestFundamentalTypes(t *testing.T) {
+	funcs := selectFromHeader(t, serviceHeader, serviceList)
+
+	tests := []struct {
+		routine string
+		param   int
+		c       string
+		rust    string
+		goType  string
+	}{
+		{"mkl_set_num_threads_local", 0, "int", "i32", "int32"},
+		{"mkl_get_cpu_clocks", 0, "uint64_t *", "*mut u64", "*uint64"},
+		{"mkl_malloc", 0, "size_t", "usize", "uint64"},
+		{"mkl_mem_stat", 0, "int *", "*mut i32", "*int32"},
+		{"mkl_get_version_string", 0, "char *", "*mut i8", "*byte"},
+		{"mkl_test_schar", 0, "signed char", "i8", "int8"},
+		{"mkl_test_schar", 1, "unsigned char *", "*mut u8", "*uint8"},
+		{"mkl_test_ushort", 0, "short", "i16", "int16"},
+		{"mkl_test_ushort", 1, "unsigned short *", "*mut u16", "*uint16"},
+		{"mkl_test_ushort", 2, "const signed char *", "*const i8", "*int8"},
+	}
+	for _, test := range tests {
+		f, found := funcs[test.routine]
+		if !found {
+			t.Fatalf("%s is not selected", test.routine)
+		}
+		p := f.args[test.param]
+		if p.typeName != test.c {
+			t.Errorf("parameter %d of %s is %q, want %q", test.param, test.routine, p.typeName, test.c)
+		}
+		if rust, _ := getRustParamType(p.typeName); rust != test.rust {
+			t.Errorf("parameter %d of %s is %q in rust, want %q", test.param, test.routine, rust, test.rust)
+		}
+		if goType := getGoParamType(p.typeName); goType != test.goType {
+			t.Errorf("parameter %d of %s is %q in go, want %q", test.param, test.routine, goType, test.goType)
+		}
+	}
+
+	returns := map[string]string{
+		"mkl_mem_stat":       "int64",
+		"mkl_peak_mem_usage": "uint64",
+		"mkl_test_schar":     "int8",
+		"mkl_test_ushort":    "uint16",
+	}
+	for routine, want := range returns {
+		if got := getGoParamType(funcs[routine].ReturnType); got != want {
+			t.Errorf("%s returns %q in go, want %q", routine, got, want)
+		}
+	}
+	// bindgen has unsigned long long as c_ulonglong, which is u64
+	if got, _ := getRustParamType(funcs["mkl_peak_mem_usage"].ReturnType); got != "u64" {
+		t.Errorf("mkl_peak_mem_usage returns %q in rust, want u64", got)
+	}
+}
+
+func TestIntegerTypeColumns(t *testing.T) {
+	for name, integer := range integerTypes {
+		columns := reflect.ValueOf(integer)
+		for i := 0; i < columns.NumField(); i++ {
+			if columns.Field(i).String() == "" {
+				t.Errorf("%s has no %s", name, columns.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestSmallIntegersInOutputs(t *testing.T) {
+	funcs := selectFromHeader(t, serviceHeader, serviceList)
+	short := funcs["mkl_test_ushort"].args[0].typeName
+	ushort := funcs["mkl_test_ushort"]
+	schar := funcs["mkl_test_schar"].args[0].typeName
+
+	zig, _ := getZigParamType(short, "f64")
+	py, _ := getPyParamType(schar, "c_double")
+	julia, _ := getJuliaParamType(short, "Float64")
+	node, _, _ := getNodeParamType(schar, "double", "Float64Array")
+	ocaml, _, _ := getOCamlParamType(schar, "double", "")
+	java, layout, _ := getJavaParamType(short, "double", "JAVA_DOUBLE")
+	tests := []struct {
+		output string
+		got    string
+		want   string
+	}{
+		{"zig", zig, "c_short"},
+		{"python", py, "c_byte"},
+		{"julia", julia, "Cshort"},
+		{"node", node, "int8_t"},
+		{"ocaml", ocaml, "schar"},
+		{"java", java + " " + layout, "short JAVA_SHORT"},
+		{"pascal", getPascalParamType(short, "Double"), "SmallInt"},
+		{"crystal", getCrystalParamType(schar, "Float64"), "Int8"},
+		{"haskell", getHaskellParamType(schar, "CDouble"), "CSChar"},
+		{"ada", getAdaParamType(short, "Long_Float"), "Interfaces.C.short"},
+		{"fortran", getFortranParamType(schar, "c_double"), "integer(c_signed_char), value"},
+		{"swift", getSwiftParamType(short), "Int16"},
+		{"kotlin", getKotlinParamType(schar, "Double", "DoubleVar"), "Byte"},
+		{"r", rShimArg("a", short, "double"), "(short)Rf_asInteger(a)"},
+		{"zig return", ushort.ZigReturn("f64"), "c_ushort"},
+		{"python return", ushort.PyRestype(), "c_ushort"},
+		{"java return", ushort.JavaReturn("double") + " " + ushort.javaReturnLayout(), "short JAVA_SHORT"},
+		{"fortran return", ushort.FortranResult(), "integer(c_short)"},
+		{"swift return", ushort.SwiftReturn(), " -> UInt16"},
+		{"kotlin return", ushort.KotlinReturn("Double"), "UShort"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("the small integer is %q in %s, want %q", test.got, test.output, test.want)
+		}
+	}
+}
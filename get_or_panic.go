@@ -1,7 +1,47 @@
 package main
 
-import "log"
+import (
+	"log"
+	"os"
+)
 
+// the exit codes of the failures, so the scripts and ci running the tool can tell them apart.
+// The other failures, such as check finding differences, exit with 1.
+const (
+	// exitInput is for the flags, the config, the function list and the other files the tool reads, except the header,
+	// including the function list failing --strict or --fail-on-deprecated.
+	exitInput = 2
+	// exitHeader is for the header failing to be read, such as for a missing include directory.
+	exitHeader = 3
+	// exitTemplate is for the templates failing to generate the output from the routines.
+	exitTemplate = 4
+	// exitOutput is for the output failing to be written or read back.
+	exitOutput = 5
+)
+
+// failf logs the failure and exits with code, without the stack trace of a panic.
+func failf(code int, format string, v ...any) {
+	log.Printf(format, v...)
+	os.Exit(code)
+}
+
+// orFail fails with code if err is not nil.
+func orFail(code int, err error) {
+	if err != nil {
+		failf(code, "%v", err)
+	}
+}
+
+// readFile is the content of the file at path that the tool reads, such as the function list, failing with exitInput if it cannot.
+func readFile(path string) []byte {
+	content, err := os.ReadFile(path)
+	orFail(exitInput, err)
+	recordRead(path)
+
+	return content
+}
+
+// orPanic panics for the errors that would be bugs of the tool, such as failing to write to a buffer.
 func orPanic(err error) {
 	if err != nil {
 		log.Panic(err)
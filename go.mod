@@ -3,7 +3,10 @@ module github.com/fardream/gen-mkl-wrapper
 go 1.22.6
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/cc/v4 v4.24.3
 	mvdan.cc/gofumpt v0.7.0
 )
@@ -12,7 +15,6 @@ require (
 	github.com/google/go-cmp v0.6.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/mod v0.20.0 // indirect
 	golang.org/x/tools v0.24.0 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
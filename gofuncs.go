@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"mvdan.cc/gofumpt/format"
+)
+
+// GoFuncs are the generic go functions, each over the float32/float64 routines and the complex64/complex128 routines of the same name.
+// When the complex routines take different parameters from the real ones, for example cblas taking complex scalars by pointer,
+// they become a separate function with Complex suffix.
+func (i *tmplInput) GoFuncs() []*GoFuncPair {
+	result := i.FuncPairs()
+	byname := make(map[string]*GoFuncPair)
+	for _, f := range result {
+		byname[f.Name] = f
+	}
+
+	bycomplexname := make(map[string]*GoFuncPair)
+	for _, c64func := range i.C64Funcs() {
+		f := &GoFuncPair{
+			Complex64Func: c64func,
+			Name:          c64func.GoName(),
+		}
+		real, ok := byname[f.Name]
+		switch {
+		case ok && slices.Equal(real.Params(), f.Params()) && real.GoReturn() == f.GoReturn():
+			real.Complex64Func = c64func
+			f = real
+		case ok:
+			f.Name += "Complex"
+			result = append(result, f)
+		default:
+			result = append(result, f)
+		}
+		bycomplexname[c64func.GoName()] = f
+	}
+
+	for _, c128func := range i.C128Funcs() {
+		f, ok := bycomplexname[c128func.GoName()]
+		if !ok {
+			failf(exitTemplate, "complex128 has name %s, which has no complex64 routine to pair with", c128func.GoName())
+		}
+		f.Complex128Func = c128func
+	}
+
+	return result
+}
+
+// getGoGroupPath is where the go functions of group are written, which is the go output with the group as the suffix,
+// such as mkl_blas.go for mkl.go.
+func getGoGroupPath(output string, group string) string {
+	return strings.TrimSuffix(output, ".go") + "_" + group + ".go"
+}
+
+// formatGo is the generated go code src formatted, failing with exitTemplate if it does not parse.
+func formatGo(src []byte) []byte {
+	r, err := format.Source(src, format.Options{LangVersion: "go1.22"})
+	if err != nil {
+		failf(exitTemplate, "the generated go code does not parse: %v", err)
+	}
+
+	return r
+}
+
+// GoNeedsUnsafe is true when the generated functions use unsafe, which is always the case for the generic ones.
+func (i *tmplInput) GoNeedsUnsafe() bool {
+	if len(i.GoFuncs()) > 0 {
+		return true
+	}
+	for _, f := range i.PlainFuncs() {
+		if strings.Contains(f.GoCall(), "unsafe.") || strings.Contains(strings.Join(f.GoParams(), ","), "unsafe.") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// first is the routine the parameters of the go function are taken from.
+func (f *GoFuncPair) first() *funcDef {
+	if f.Float32Func != nil {
+		return f.Float32Func
+	}
+
+	return f.Complex64Func
+}
+
+// goCase is a routine of the go function, for the type switch on F.
+type goCase struct {
+	*funcDef
+	// GoType is the go type the routine is called for.
+	GoType string
+}
+
+// GoCases are the routines dispatched by the go function.
+func (f *GoFuncPair) GoCases() []*goCase {
+	r := []*goCase{}
+	for _, c := range []*goCase{
+		{f.Float64Func, "float64"},
+		{f.Float32Func, "float32"},
+		{f.Complex128Func, "complex128"},
+		{f.Complex64Func, "complex64"},
+	} {
+		if c.funcDef != nil {
+			r = append(r, c)
+		}
+	}
+
+	return r
+}
+
+// GoConstraint is the type set of F.
+func (f *GoFuncPair) GoConstraint() string {
+	r := []string{}
+	for _, c := range f.GoCases() {
+		r = append(r, "~"+c.GoType)
+	}
+
+	return strings.Join(r, " | ")
+}
+
+var goReservedNames = map[string]struct{}{
+	// names used by the generated functions
+	"C": {}, "F": {}, "t": {}, "ret": {}, "unsafe": {},
+	"break": {}, "case": {}, "chan": {}, "const": {}, "continue": {}, "default": {}, "defer": {}, "else": {},
+	"fallthrough": {}, "for": {}, "func": {}, "go": {}, "goto": {}, "if": {}, "import": {}, "interface": {},
+	"map": {}, "package": {}, "range": {}, "return": {}, "select": {}, "struct": {}, "switch": {}, "type": {}, "var": {},
+}
+
+// goParamName makes the c parameter name a valid go parameter name, which does not shadow the names used by the generated functions,
+// for example the C of cblas_?gemm.
+func goParamName(name string) string {
+	if _, isReserved := goReservedNames[name]; isReserved {
+		return name + "_"
+	}
+
+	return name
+}
+
+// goParamType is getGoParamType, except cblas passes the complex numbers as void pointers for complex routines.
+func (f *funcDef) goParamType(t string) string {
+	if f.isComplex() {
+		switch strings.TrimSuffix(t, "[]") {
+		case "const void *", "void *", "const void", "void":
+			return "*F"
+		case "const void **", "void **", "const void * const *", "void * const *":
+			return "**F"
+		}
+	}
+
+	return getGoParamType(t)
+}
+
+// goFloatTypes are the go types of the float and complex types.
+var goFloatTypes = map[string]string{
+	"double":        "float64",
+	"float":         "float32",
+	"MKL_Complex16": "complex128",
+	"MKL_Complex8":  "complex64",
+}
+
+// goSelf is the F of the generic functions for float or complex type t, or the go type of t for the plain functions,
+// which are not generic, such as float64 for cblas_ddot listed without wildcards.
+func (f *funcDef) goSelf(t string) string {
+	if f.elem != noElem {
+		return "F"
+	}
+
+	return goFloatTypes[cBaseType(t)]
+}
+
+// cgoSelf is the cgo type of the float or complex type.
+func (f *funcDef) cgoSelf() string {
+	switch f.elem {
+	case float32Elem:
+		return "C.float"
+	case complex128Elem:
+		return "C.MKL_Complex16"
+	case complex64Elem:
+		return "C.MKL_Complex8"
+	default:
+		return "C.double"
+	}
+}
+
+// cgoDeclType is the cgo type of the c type t as declared in the header, with the pointer and const removed.
+// cgo keeps typedefs distinct, so MKL_INT64 cannot be passed as C.int64_t.
+func cgoDeclType(t string) string {
+	t = strings.TrimPrefix(strings.TrimSuffix(t, " *"), "const ")
+	if name, isMultiToken := cgoTypeNames[t]; isMultiToken {
+		t = name
+	}
+	if tag, isEnum := strings.CutPrefix(t, "enum "); isEnum {
+		t = "enum_" + tag
+	}
+	return "C." + t
+}
+
+// cgoTypeNames are the cgo names of the c types spelled with several tokens, in their canonical spelling.
+var cgoTypeNames = map[string]string{
+	"signed char":        "schar",
+	"unsigned char":      "uchar",
+	"unsigned short":     "ushort",
+	"unsigned int":       "uint",
+	"unsigned long":      "ulong",
+	"long long":          "longlong",
+	"unsigned long long": "ulonglong",
+	"float _Complex":     "complexfloat",
+	"double _Complex":    "complexdouble",
+}
+
+// cgoArg converts the go parameter called name of parameter p to its cgo type.
+func (f *funcDef) cgoArg(name string, p funcArg) string {
+	t := p.typeName
+	goType := f.goParamType(t)
+	self := f.cgoSelf()
+	// the complex types are declared as MKL_Complex8 by mkl, and as float _Complex by lapacke.h of openblas,
+	// and the real routines take them as the parts of F, such as the output of fftw_plan_dft_r2c_1d
+	base := cBaseType(t)
+	if f.elem == noElem || (f.isComplex() && base == f.elem.cType()) || (f.isReal() && (base == "MKL_Complex8" || base == "MKL_Complex16")) {
+		self = cgoDeclType(cBaseType(p.declType))
+	}
+	// the types from the type map are converted as the types declared in the header
+	if isUserGoType(t) {
+		if _, _, isPointer := cutPointer(t); isPointer {
+			return fmt.Sprintf("(*%s)(unsafe.Pointer(%s))", cgoDeclType(p.declType), name)
+		}
+		return fmt.Sprintf("%s(%s)", cgoDeclType(p.declType), name)
+	}
+	if _, isPrecision := precisionTypedef(t); isPrecision {
+		return fmt.Sprintf("%s(%s)", cgoDeclType(p.declType), name)
+	}
+	switch goType {
+	case "*F":
+		if strings.Contains(t, "void") {
+			return fmt.Sprintf("unsafe.Pointer(%s)", name)
+		}
+		return fmt.Sprintf("(*%s)(unsafe.Pointer(%s))", self, name)
+	case "**F":
+		if strings.Contains(t, "void") {
+			return fmt.Sprintf("(*unsafe.Pointer)(unsafe.Pointer(%s))", name)
+		}
+		return fmt.Sprintf("(**%s)(unsafe.Pointer(%s))", self, name)
+	case "F":
+		return fmt.Sprintf("*(*%s)(unsafe.Pointer(&%s))", self, name)
+	case "*int32":
+		return fmt.Sprintf("(*C.int)(unsafe.Pointer(%s))", name)
+	case "**int32":
+		return fmt.Sprintf("(**C.int)(unsafe.Pointer(%s))", name)
+	case "*int64", "*uint64", "*uint32", "*int8", "*uint8", "*int16", "*uint16", "*byte":
+		return fmt.Sprintf("(*%s)(unsafe.Pointer(%s))", cgoDeclType(p.declType), name)
+	case "int32":
+		return fmt.Sprintf("C.int(%s)", name)
+	case "int64", "uint64", "uint32", "int8", "uint8", "int16", "uint16":
+		return fmt.Sprintf("%s(%s)", cgoDeclType(p.declType), name)
+	case "byte":
+		return fmt.Sprintf("C.char(%s)", name)
+	case "unsafe.Pointer", "*unsafe.Pointer", "*[0]byte":
+		return name
+	}
+
+	// the enums declared by the go output are converted to the types declared in the header, such as C.enum_DFTI_CONFIG_PARAM
+	if isGoEnum(goType) {
+		return fmt.Sprintf("%s(%s)", cgoDeclType(p.declType), name)
+	}
+	if isGoEnum(strings.TrimPrefix(goType, "*")) {
+		return fmt.Sprintf("(*%s)(unsafe.Pointer(%s))", cgoDeclType(p.declType), name)
+	}
+
+	if strings.HasPrefix(strings.TrimLeft(goType, "*"), "C.") {
+		// the enums declared by their tag, such as const enum CBLAS_ORDER of accelerate, are converted from their typedefs
+		if declType := cgoDeclType(p.declType); declType != goType && strings.HasPrefix(declType, "C.enum_") {
+			return fmt.Sprintf("%s(%s)", declType, name)
+		}
+		return name
+	}
+
+	return fmt.Sprintf("C.%s(%s)", goType, name)
+}
+
+// cgoName is the c function the go function calls.
+// cgo cannot call variadic functions, such as DftiComputeForward, so they are called through the shims of GoVariadicShims.
+func (f *funcDef) cgoName() string {
+	if f.variadic {
+		return f.RawName + "_fixed"
+	}
+
+	return f.RawName
+}
+
+// GoVariadicShims are the static inline functions in the cgo preamble calling the variadic routines with their fixed parameters.
+func (i *tmplInput) GoVariadicShims() []string {
+	r := []string{}
+	for _, f := range i.groupFuncs() {
+		if !f.variadic {
+			continue
+		}
+		ret := ""
+		if f.HasReturn() {
+			ret = "return "
+		}
+		r = append(r, fmt.Sprintf("static inline %s %s(%s) { %s%s(%s); }", f.ReturnType, f.cgoName(), f.CParams(), ret, f.RawName, f.CInput()))
+	}
+
+	return r
+}
+
+// GoCall is the cgo call to the routine, converting the arguments and the return value.
+func (f *funcDef) GoCall() string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, f.cgoArg(goParamName(p.name), p))
+	}
+	call := fmt.Sprintf("C.%s(%s)", f.cgoName(), strings.Join(r, ", "))
+
+	// the types from the type map are converted from the types declared in the header
+	if t, found := lookupType(userTypes.Go, f.ReturnType); found {
+		if _, _, isPointer := cutPointer(f.ReturnType); isPointer {
+			return fmt.Sprintf("return (%s)(unsafe.Pointer(%s))", t, call)
+		}
+		return fmt.Sprintf("return %s(%s)", t, call)
+	}
+
+	if _, isPrecision := precisionTypedef(f.ReturnType); isPrecision {
+		return fmt.Sprintf("return unsafe.Pointer(%s)", call)
+	}
+
+	if integer, isInteger := integerTypes[f.ReturnType]; isInteger {
+		return fmt.Sprintf("return %s(%s)", integer.goType, call)
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return call
+	case "float", "double", "MKL_Complex8", "MKL_Complex16":
+		return fmt.Sprintf("ret := %s\n\t\treturn *(*%s)(unsafe.Pointer(&ret))", call, f.goSelf(f.ReturnType))
+	default:
+		return fmt.Sprintf("return %s", call)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// generateFromHeader writes header as mkl.h and the function list list to a temporary directory,
+// and generates the output named output there with the output flag lang set, such as forGo.
+func generateFromHeader(t *testing.T, header string, list string, lang *bool, output string) (string, []generatedFile) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"mkl.h": header, "list.txt": list} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previousPath, previousCache, previousInputs, previousOutput := mklPath, headerCache, inputFuncsPaths, outputFile
+	mklPath, headerCache = filepath.Join(dir, "mkl.h"), false
+	inputFuncsPaths, outputFile = []string{filepath.Join(dir, "list.txt")}, filepath.Join(dir, output)
+	*lang = true
+	t.Cleanup(func() {
+		mklPath, headerCache, inputFuncsPaths, outputFile = previousPath, previousCache, previousInputs, previousOutput
+		*lang = false
+		resetRoutineState()
+	})
+
+	return dir, generate()
+}
+
+const gemmHeader = `
+typedef enum {CblasRowMajor=101, CblasColMajor=102} CBLAS_LAYOUT;
+typedef enum {CblasNoTrans=111, CblasTrans=112, CblasConjTrans=113} CBLAS_TRANSPOSE;
+typedef enum {CblasUpper=121, CblasLower=122} CBLAS_UPLO;
+typedef enum {CblasNonUnit=131, CblasUnit=132} CBLAS_DIAG;
+typedef enum {CblasLeft=141, CblasRight=142} CBLAS_SIDE;
+void cblas_sgemm(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const CBLAS_TRANSPOSE TransB, const int M, const int N, const int K,
+	const float alpha, const float *A, const int lda, const float *B, const int ldb, const float beta, float *C, const int ldc);
+void cblas_dgemm(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const CBLAS_TRANSPOSE TransB, const int M, const int N, const int K,
+	const double alpha, const double *A, const int lda, const double *B, const int ldb, const double beta, double *C, const int ldc);
+void cblas_dgemm_batch(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE *TransA_Array, const CBLAS_TRANSPOSE *TransB_Array,
+	const int *M_Array, const int *N_Array, const int *K_Array, const double *alpha_Array, const double **A_Array, const int *lda_Array,
+	const double **B_Array, const int *ldb_Array, const double *beta_Array, double **C_Array, const int *ldc_Array,
+	const int group_count, const int *group_size);
+void cblas_dtrsv(const CBLAS_LAYOUT Layout, CBLAS_UPLO Uplo, CBLAS_TRANSPOSE TransA, CBLAS_DIAG Diag,
+	const int N, const double *A, const int lda, double *X, const int incX);
+`
+
+// the caller is in a package of its own, so it only builds if the wrappers take the enums the package declares
+const gemmCaller = `package caller
+
+import mkl "example.com/mkltest/mklroutines"
+
+func Gemm(a, b, c []float64, n int32) {
+	mkl.Cblas_gemm(mkl.CblasRowMajor, mkl.CblasNoTrans, mkl.CblasTrans, n, n, n, 1, &a[0], n, &b[0], n, 0, &c[0], n)
+}
+
+func GemmBatch(a, b, c []*float64, n []int32) {
+	trans := []mkl.CBLAS_TRANSPOSE{mkl.CblasNoTrans}
+	alpha, beta := []float64{1}, []float64{0}
+	mkl.Cblas_dgemm_batch(mkl.CblasColMajor, &trans[0], &trans[0], &n[0], &n[0], &n[0], &alpha[0], &a[0], &n[0], &b[0], &n[0], &beta[0], &c[0], &n[0], 1, &n[0])
+}
+
+func Trsv(a, x []float64, n int32) {
+	mkl.Cblas_dtrsv(mkl.CblasRowMajor, mkl.CblasUpper, mkl.CblasNoTrans, mkl.CblasNonUnit, n, &a[0], n, &x[0], 1)
+}
+`
+
+func TestGoEnumsFromOtherPackage(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not in PATH")
+	}
+	if out, err := exec.Command(goBin, "env", "CGO_ENABLED").Output(); err != nil || string(out) != "1\n" {
+		t.Skip("cgo is not enabled")
+	}
+
+	dir, files := generateFromHeader(t, gemmHeader, "cblas_*gemm\ncblas_dgemm_batch\ncblas_dtrsv\n", &forGo, "mklroutines/mkl.go")
+	module := map[string][]byte{
+		filepath.Join(dir, "go.mod"):            []byte("module example.com/mkltest\n\ngo 1.22\n"),
+		filepath.Join(dir, "mklroutines/mkl.h"): []byte(gemmHeader),
+		filepath.Join(dir, "caller/caller.go"):  []byte(gemmCaller),
+	}
+	for _, f := range files {
+		module[f.path] = f.content
+	}
+	for path, content := range module {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "build", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CGO_CFLAGS=-I"+filepath.Join(dir, "mklroutines"), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("the generated package and its caller fail to build: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// goGenerate tunes the tool for //go:generate, from the GOFILE, GOLINE and GOPACKAGE environment variables go generate sets.
+var goGenerate = false
+
+// startGoGenerate resolves the paths relative to the directory of GOFILE, and logs the failures at the
+// //go:generate line, as file:line: message, which go generate and the editors point to.
+// It runs before the config, which is read from the directory of GOFILE.
+func startGoGenerate() {
+	goFile := os.Getenv("GOFILE")
+	if goFile == "" {
+		failf(exitInput, "--go-generate runs under go generate, which sets GOFILE")
+	}
+
+	log.SetFlags(0)
+	log.SetPrefix(goFile + ":" + os.Getenv("GOLINE") + ": ")
+	// go generate runs in the directory of GOFILE, which is then "."
+	if dir := filepath.Dir(goFile); dir != "." {
+		orFail(exitInput, os.Chdir(dir))
+	}
+}
+
+// applyGoGenerate fills the flags not set by the command line, the environment variables or the config
+// with the ones for the go file of //go:generate: go, in the package of GOFILE, written next to it as GOFILE with _mkl,
+// and quiet unless -v.
+// They are not recorded in the banner, which records --go-generate instead.
+func applyGoGenerate(cmd *cobra.Command) {
+	forOther := false
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		forOther = forOther || (flag.Changed && strings.HasPrefix(flag.Name, "for-"))
+	})
+	if !forOther {
+		forGo = true
+	}
+
+	if !cmd.Flags().Changed("gopkg") {
+		goPackageName = os.Getenv("GOPACKAGE")
+		if goPackageName == "" {
+			wd, err := os.Getwd()
+			orFail(exitInput, err)
+			goPackageName = filepath.Base(wd)
+		}
+	}
+
+	if !cmd.Flags().Changed("output") {
+		outputFile = strings.TrimSuffix(filepath.Base(os.Getenv("GOFILE")), ".go") + "_mkl.go"
+	}
+
+	if verbosity == 0 {
+		quiet = true
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//go:embed haskell.tmpl
+var haskellTmplText string
+
+var (
+	forHaskell        = false
+	haskellModuleName = "MKLRoutines"
+)
+
+func (*tmplInput) HaskellModuleName() string {
+	return haskellModuleName
+}
+
+func (f *funcDef) HaskellName() string {
+	return lowerLeading(f.BetterName)
+}
+
+func (c *constDef) HaskellName() string {
+	return lowerLeading(c.Name)
+}
+
+// HaskellSelf is the haskell type of the float type.
+func (f *funcDef) HaskellSelf() string {
+	if f.is32() {
+		return "Float"
+	}
+	return "Double"
+}
+
+// getHaskellParamType returns the haskell ffi type of c type t, with self as the float type.
+// Types that are not known are passed as Ptr ().
+func getHaskellParamType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.haskellType
+	}
+
+	if fn, isFunc := funcPointerTypes[t]; isFunc {
+		r := []string{}
+		for _, arg := range fn.args {
+			r = append(r, getHaskellParamType(arg.typeName, self))
+		}
+		r = append(r, getHaskellReturnType(fn.returnType, self))
+		return fmt.Sprintf("FunPtr (%s)", strings.Join(r, " -> "))
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("Ptr %s", self)
+	case "double", "float", "const double", "const float":
+		return self
+	case "char", "const char":
+		return "CChar"
+	case "const char *":
+		return "Ptr CChar"
+	case "int *", "const int *":
+		return "Ptr CInt"
+	case "int64_t *", "const int64_t *":
+		return "Ptr Int64"
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "CInt"
+	case "sparse_matrix_t *", "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		return "Ptr (Ptr ())"
+	}
+
+	if isEnum(t) {
+		return "CInt"
+	}
+
+	// pointer to pointer, such as the arrays of the batch routines
+	if pointee, _, isPointer := cutPointer(t); isPointer && strings.HasSuffix(pointee, "*") {
+		return fmt.Sprintf("Ptr (%s)", getHaskellParamType(pointee, self))
+	}
+
+	return "Ptr ()"
+}
+
+func getHaskellReturnType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return "IO " + integer.haskellType
+	}
+
+	switch t {
+	case "void":
+		return "IO ()"
+	case "float", "double":
+		return fmt.Sprintf("IO %s", self)
+	}
+	if isEnum(t) {
+		return "IO CInt"
+	}
+
+	return "IO (Ptr ())"
+}
+
+// haskellFuncDefs leaves out the routines taking or returning structs by value, such as mkl_sparse_?_mv taking struct matrix_descr
+// or cblas_zdotu returning MKL_Complex16, which the haskell ffi cannot pass.
+func haskellFuncDefs(funcs []funcDef) []funcDef {
+	r := make([]funcDef, 0, len(funcs))
+	for _, f := range funcs {
+		if i := slices.IndexFunc(f.args, func(p funcArg) bool { return isStruct(p.typeName) }); i >= 0 {
+			warnf("skipping %s for haskell, which cannot pass %s by value", f.RawName, f.args[i].typeName)
+			continue
+		}
+		if isStruct(f.ReturnType) {
+			warnf("skipping %s for haskell, which cannot return %s by value", f.RawName, f.ReturnType)
+			continue
+		}
+		r = append(r, f)
+	}
+
+	return r
+}
+
+// HaskellType is the type signature of the routine, with self as the float type.
+func (f *funcDef) HaskellType(self string) string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, getHaskellParamType(p.typeName, self))
+	}
+	r = append(r, getHaskellReturnType(f.ReturnType, self))
+
+	return strings.Join(r, " -> ")
+}
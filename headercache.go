@@ -0,0 +1,506 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+var (
+	// headerCache keeps the lines of the declarations the prefilter keeps on the disk, so the runs reading the same headers
+	// with the same macros for the same routines skip preprocessing them, which is most of the time of reading the header,
+	// and the routines selected, so the runs with the same flags too skip translating the header.
+	headerCache = true
+	// headerCacheDir is the directory of the cache, or gen-mkl-wrapper in the cache directory of the user when empty,
+	// such as ~/.cache/gen-mkl-wrapper on linux.
+	headerCacheDir = ""
+)
+
+// headerCacheVersion is the version of the format of the cache and the way the lines are kept,
+// which is part of the key so the caches of the builds keeping the lines differently are not read.
+const headerCacheVersion = "1"
+
+// cachedHeader is the lines of the declarations kept by the headers, cached with the sha256 of the headers read,
+// which the preprocessing depends on besides the key.
+type cachedHeader struct {
+	// Files are the hex sha256 of the headers read by their paths.
+	Files map[string]string `json:"files"`
+	// Kept are the first and the last lines of the declarations kept by the headers, the last being 0 for the end of the header.
+	Kept map[string][][2]int `json:"kept"`
+}
+
+// ccVersion is the version of modernc.org/cc the tool is built with, which the declarations kept may change with.
+func ccVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "modernc.org/cc/v4" {
+			return dep.Version
+		}
+	}
+
+	return ""
+}
+
+// headerCacheFile is the file of the cache for the header parsed by key, the target, the macros, the include paths,
+// the headers and the routines, with the system include paths of compiler and the versions of the tool and cc,
+// or empty without --header-cache.
+func headerCacheFile(key string, compiler *cc.Config) string {
+	if !headerCache {
+		return ""
+	}
+	dir := headerCacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			debugf("no cache of the header: %v", err)
+			return ""
+		}
+		dir = filepath.Join(userDir, "gen-mkl-wrapper")
+	}
+
+	parts := append([]string{headerCacheVersion, toolVersion(), ccVersion(), key}, compiler.SysIncludePaths...)
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+
+	return filepath.Join(dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// fileHash is the hex sha256 of the content of file path, or empty if it cannot be read.
+func fileHash(path string) string {
+	content, err := os.ReadFile(filepath.FromSlash(path))
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// loadCachedHeader is the lines kept of the cache file, if it has them and none of the headers read changed since.
+func loadCachedHeader(file string) (map[string][]lineRange, bool) {
+	if file == "" {
+		return nil, false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedHeader
+	if err := json.Unmarshal(content, &cached); err != nil {
+		debugf("ignoring the cache of the header %s: %v", file, err)
+		return nil, false
+	}
+	for path, hash := range cached.Files {
+		if fileHash(path) != hash {
+			debugf("ignoring the cache of the header %s, as %s changed", file, path)
+			return nil, false
+		}
+	}
+
+	kept := make(map[string][]lineRange, len(cached.Kept))
+	for path, ranges := range cached.Kept {
+		for _, r := range ranges {
+			kept[path] = append(kept[path], lineRange{from: r[0], to: r[1]})
+		}
+	}
+	infof("read the declarations kept from the cache %s", file)
+
+	return kept, true
+}
+
+// storeCachedHeader writes the lines kept of headers paths to the cache file, which is written in place at once,
+// so the runs in parallel read either all of it or none. The header is read without the cache if it cannot be written.
+func storeCachedHeader(file string, paths []string, kept map[string][]lineRange) {
+	if file == "" {
+		return
+	}
+	cached := cachedHeader{Files: make(map[string]string, len(paths)), Kept: make(map[string][][2]int, len(kept))}
+	for _, path := range paths {
+		cached.Files[path] = fileHash(path)
+	}
+	for path, ranges := range kept {
+		cached.Kept[path] = [][2]int{}
+		for _, r := range ranges {
+			cached.Kept[path] = append(cached.Kept[path], [2]int{r.from, r.to})
+		}
+	}
+	content, err := json.Marshal(cached)
+	if err != nil {
+		debugf("cannot cache the header: %v", err)
+		return
+	}
+
+	writeCacheFile(file, content)
+}
+
+// writeCacheFile writes content to the cache file in place at once, so the runs in parallel read either all of it or none.
+func writeCacheFile(file string, content []byte) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		debugf("cannot write the cache %s: %v", file, err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "header-*.tmp")
+	if err != nil {
+		debugf("cannot write the cache %s: %v", file, err)
+		return
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		debugf("cannot write the cache %s: %v", file, err)
+	}
+}
+
+// cachedRoutines are the routines the function list selects from the headers, with the constants, the enums and the structs they take,
+// cached with the sha256 of the files read, so the runs reading the same headers for the same routines with the same flags skip translating them.
+type cachedRoutines struct {
+	// Files are the hex sha256 of the files read by their paths, the headers and the files of the flags.
+	Files     map[string]string `json:"files"`
+	List      cachedList        `json:"list"`
+	Funcs     []cachedFunc      `json:"funcs"`
+	Constants []*constGroup     `json:"constants"`
+	Enums     []*enumDef        `json:"enums"`
+	Structs   []cachedStruct    `json:"structs"`
+	// OtherEnums and EnumTags are otherEnumNames and enumTags of the routines.
+	OtherEnums []string `json:"other_enums"`
+	EnumTags   []string `json:"enum_tags"`
+	Typedefs   []string `json:"typedefs"`
+	CblasEnums bool     `json:"cblas_enums"`
+	Header     string   `json:"header"`
+}
+
+// cachedList is the function list once the header is read, as funcListInput.
+type cachedList struct {
+	Names     map[string]cachedName `json:"names"`
+	Desired   []string              `json:"desired"`
+	Consts    []string              `json:"consts"`
+	Plain     map[string]string     `json:"plain"`
+	Excluded  []string              `json:"excluded"`
+	Groups    []string              `json:"groups"`
+	Lines     map[string]string     `json:"lines"`
+	Unmatched []cachedUnmatched     `json:"unmatched"`
+}
+
+// cachedName is funcName of the function list.
+type cachedName struct {
+	BetterName string   `json:"better_name"`
+	Elem       elemType `json:"elem"`
+	Out        elemType `json:"out"`
+	Group      string   `json:"group"`
+	Renamed    bool     `json:"renamed"`
+	UnsafeOnly bool     `json:"unsafe_only"`
+}
+
+// cachedUnmatched is unmatchedEntry of the function list.
+type cachedUnmatched struct {
+	Line    string   `json:"line"`
+	Found   []string `json:"found"`
+	Missing []string `json:"missing"`
+}
+
+// cachedFunc is funcDef of a routine.
+type cachedFunc struct {
+	RawName        string      `json:"raw_name"`
+	Elem           elemType    `json:"elem"`
+	Out            elemType    `json:"out"`
+	ReturnType     string      `json:"return_type"`
+	Args           []cachedArg `json:"args"`
+	BetterName     string      `json:"better_name"`
+	Declaration    string      `json:"declaration"`
+	DeclReturnType string      `json:"decl_return_type"`
+	Header         string      `json:"header"`
+	Line           int         `json:"line"`
+	Deprecated     bool        `json:"deprecated"`
+	Variadic       bool        `json:"variadic"`
+	Group          string      `json:"group"`
+	Renamed        bool        `json:"renamed"`
+	UnsafeOnly     bool        `json:"unsafe_only"`
+}
+
+// cachedArg is funcArg of a parameter or a field.
+type cachedArg struct {
+	Name     string `json:"name"`
+	TypeName string `json:"type_name"`
+	DeclType string `json:"decl_type"`
+	Extent   string `json:"extent,omitempty"`
+	RustName string `json:"rust_name,omitempty"`
+	DontUse  bool   `json:"dont_use,omitempty"`
+	CgoType  string `json:"cgo_type,omitempty"`
+	GoType   string `json:"go_type,omitempty"`
+	// Fn is the function pointer of the parameter, if it is one.
+	Fn *cachedFuncPointer `json:"fn,omitempty"`
+}
+
+// cachedFuncPointer is funcPointer of a parameter.
+type cachedFuncPointer struct {
+	ReturnType string      `json:"return_type"`
+	Args       []cachedArg `json:"args"`
+}
+
+// cachedStruct is structDef of a struct taken by value.
+type cachedStruct struct {
+	Name   string      `json:"name"`
+	Fields []cachedArg `json:"fields"`
+}
+
+// routinesCacheFile is the file of the cache for the routines function list content selects from the header parsed by key,
+// with the flags of the outputs, or empty without --header-cache.
+func routinesCacheFile(key string, content string, compiler *cc.Config) string {
+	return headerCacheFile(strings.Join(append([]string{"routines", key, content}, outputFlags...), "\n"), compiler)
+}
+
+// loadCachedRoutines is the function list and the input of the templates of the cache file,
+// if it has them and none of the files read changed since. The files are recorded as read, as if the header was translated.
+func loadCachedRoutines(file string) (*funcListInput, *tmplInput, bool) {
+	if file == "" {
+		return nil, nil, false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, false
+	}
+	var cached cachedRoutines
+	if err := json.Unmarshal(content, &cached); err != nil {
+		debugf("ignoring the cache of the routines %s: %v", file, err)
+		return nil, nil, false
+	}
+	for path, hash := range cached.Files {
+		if fileHash(path) != hash {
+			debugf("ignoring the cache of the routines %s, as %s changed", file, path)
+			return nil, nil, false
+		}
+	}
+	for path := range cached.Files {
+		recordRead(path)
+	}
+
+	flist := cached.List.funcList()
+	funcs := make([]funcDef, 0, len(cached.Funcs))
+	for _, f := range cached.Funcs {
+		funcs = append(funcs, f.funcDef())
+	}
+	for _, e := range cached.Enums {
+		enumNames[e.Name] = struct{}{}
+	}
+	for _, name := range cached.OtherEnums {
+		otherEnumNames[name] = struct{}{}
+	}
+	for _, name := range cached.EnumTags {
+		enumTags[name] = struct{}{}
+	}
+	structs := make([]*structDef, 0, len(cached.Structs))
+	for _, s := range cached.Structs {
+		def := &structDef{Name: s.Name, fields: funcArgs(s.Fields)}
+		structNames[s.Name] = def
+		structs = append(structs, def)
+	}
+	infof("read the %d routines from the cache %s", len(funcs), file)
+
+	// the warnings of the function list and of the deprecated routines are given as when the header is translated
+	checkUnmatched(flist.unmatched)
+	funcs = filterDeprecated(funcs)
+	input := &tmplInput{
+		funcDefs:        funcs,
+		providerCrate:   mklProviderCrate,
+		DesiredFuncList: flist.desiredFuncList,
+		Includes:        headerIncludes(),
+		VSLConstants:    cached.Constants,
+		Enums:           cached.Enums,
+		Structs:         structs,
+		typedefs:        cached.Typedefs,
+		goCblasEnums:    cached.CblasEnums,
+		headerVersion:   cached.Header,
+		banner:          newBanner(cached.Header, flist.desiredFuncList),
+	}
+	recordVSLTypes(input.VSLConstants)
+	if reportPath != "" {
+		writeReport(reportPath, flist.unmatched, funcs)
+	}
+
+	return flist, input, true
+}
+
+// storeCachedRoutines writes the function list flist and the input of the templates to the cache file,
+// with the files read so far. The routines are selected from the header again if it cannot be written.
+func storeCachedRoutines(file string, flist *funcListInput, input *tmplInput) {
+	if file == "" {
+		return
+	}
+	readPathsMu.Lock()
+	paths := slices.Clone(readPaths)
+	readPathsMu.Unlock()
+
+	cached := cachedRoutines{
+		Files:      make(map[string]string, len(paths)),
+		List:       newCachedList(flist),
+		Funcs:      make([]cachedFunc, 0, len(input.funcDefs)),
+		Constants:  input.VSLConstants,
+		Enums:      input.Enums,
+		OtherEnums: sortedNames(otherEnumNames),
+		EnumTags:   sortedNames(enumTags),
+		Typedefs:   input.typedefs,
+		CblasEnums: input.goCblasEnums,
+		Header:     input.headerVersion,
+	}
+	for _, path := range paths {
+		cached.Files[path] = fileHash(path)
+	}
+	for _, f := range input.funcDefs {
+		cached.Funcs = append(cached.Funcs, newCachedFunc(f))
+	}
+	for _, s := range input.Structs {
+		cached.Structs = append(cached.Structs, cachedStruct{Name: s.Name, Fields: cachedArgs(s.fields)})
+	}
+	content, err := json.Marshal(cached)
+	if err != nil {
+		debugf("cannot cache the routines: %v", err)
+		return
+	}
+
+	writeCacheFile(file, content)
+}
+
+// sortedNames are the names of set in order.
+func sortedNames(set map[string]struct{}) []string {
+	r := make([]string, 0, len(set))
+	for name := range set {
+		r = append(r, name)
+	}
+	slices.Sort(r)
+
+	return r
+}
+
+func newCachedList(f *funcListInput) cachedList {
+	l := cachedList{
+		Names:    make(map[string]cachedName, len(f.names)),
+		Desired:  f.desiredFuncList,
+		Consts:   f.constNames,
+		Plain:    f.plainNames,
+		Excluded: sortedNames(f.excluded),
+		Groups:   f.groups,
+		Lines:    f.lines,
+	}
+	for name, n := range f.names {
+		l.Names[name] = cachedName{BetterName: n.betterName, Elem: n.elem, Out: n.out, Group: n.group, Renamed: n.renamed, UnsafeOnly: n.unsafeOnly}
+	}
+	for _, u := range f.unmatched {
+		l.Unmatched = append(l.Unmatched, cachedUnmatched{Line: u.line, Found: u.found, Missing: u.missing})
+	}
+
+	return l
+}
+
+func (l cachedList) funcList() *funcListInput {
+	f := &funcListInput{
+		names:           make(map[string]funcName, len(l.Names)),
+		desiredFuncList: l.Desired,
+		constNames:      l.Consts,
+		plainNames:      l.Plain,
+		excluded:        make(map[string]struct{}, len(l.Excluded)),
+		groups:          l.Groups,
+		lines:           l.Lines,
+	}
+	for name, n := range l.Names {
+		f.names[name] = funcName{
+			betterName: n.BetterName, elem: n.Elem, out: n.Out, group: n.Group, renamed: n.Renamed, unsafeOnly: n.UnsafeOnly,
+		}
+	}
+	for _, name := range l.Excluded {
+		f.excluded[name] = struct{}{}
+	}
+	for _, u := range l.Unmatched {
+		f.unmatched = append(f.unmatched, unmatchedEntry{line: u.Line, found: u.Found, missing: u.Missing})
+	}
+
+	return f
+}
+
+func newCachedFunc(f funcDef) cachedFunc {
+	return cachedFunc{
+		RawName:        f.RawName,
+		Elem:           f.elem,
+		Out:            f.out,
+		ReturnType:     f.ReturnType,
+		Args:           cachedArgs(f.args),
+		BetterName:     f.BetterName,
+		Declaration:    f.Declaration,
+		DeclReturnType: f.declReturnType,
+		Header:         f.header,
+		Line:           f.line,
+		Deprecated:     f.deprecated,
+		Variadic:       f.variadic,
+		Group:          f.group,
+		Renamed:        f.renamed,
+		UnsafeOnly:     f.unsafeOnly,
+	}
+}
+
+func (f cachedFunc) funcDef() funcDef {
+	return funcDef{
+		RawName:        f.RawName,
+		elem:           f.Elem,
+		out:            f.Out,
+		ReturnType:     f.ReturnType,
+		args:           funcArgs(f.Args),
+		BetterName:     f.BetterName,
+		Declaration:    f.Declaration,
+		declReturnType: f.DeclReturnType,
+		header:         f.Header,
+		line:           f.Line,
+		deprecated:     f.Deprecated,
+		variadic:       f.Variadic,
+		group:          f.Group,
+		renamed:        f.Renamed,
+		unsafeOnly:     f.UnsafeOnly,
+	}
+}
+
+func cachedArgs(args []funcArg) []cachedArg {
+	r := make([]cachedArg, 0, len(args))
+	for _, p := range args {
+		a := cachedArg{
+			Name: p.name, TypeName: p.typeName, DeclType: p.declType, Extent: p.extent,
+			RustName: p.rustName, DontUse: p.dontUse, CgoType: p.cgoType, GoType: p.goType,
+		}
+		if p.fn != nil {
+			a.Fn = &cachedFuncPointer{ReturnType: p.fn.returnType, Args: cachedArgs(p.fn.args)}
+		}
+		r = append(r, a)
+	}
+
+	return r
+}
+
+// funcArgs are the parameters of args, with their function pointers recorded in funcPointerTypes as when they are read from the header.
+func funcArgs(args []cachedArg) []funcArg {
+	r := make([]funcArg, 0, len(args))
+	for _, a := range args {
+		p := funcArg{
+			name: a.Name, typeName: a.TypeName, declType: a.DeclType, extent: a.Extent,
+			rustName: a.RustName, dontUse: a.DontUse, cgoType: a.CgoType, goType: a.GoType,
+		}
+		if a.Fn != nil {
+			p.fn = &funcPointer{returnType: a.Fn.ReturnType, args: funcArgs(a.Fn.Args)}
+			funcPointerTypes[p.fn.cType()] = p.fn
+		}
+		r = append(r, p)
+	}
+
+	return r
+}
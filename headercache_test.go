@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// the routines taking an enum, a struct by value and a function pointer, which the cache keeps besides the routines
+const sparseHeader = `
+typedef enum {CblasRowMajor=101, CblasColMajor=102} CBLAS_LAYOUT;
+typedef enum {SPARSE_STATUS_SUCCESS=0, SPARSE_STATUS_NOT_INITIALIZED=1} sparse_status_t;
+struct matrix_descr { CBLAS_LAYOUT layout; int mode; };
+typedef int (*mkl_progress_t)(int *thread, int *step, char *stage, int lstage);
+void cblas_dscal(const int N, const double alpha, double *X, const int incX);
+void cblas_sscal(const int N, const float alpha, float *X, const int incX);
+sparse_status_t mkl_sparse_d_check(CBLAS_LAYOUT layout, const struct matrix_descr descr);
+int mkl_set_callback(int (*callback)(const double *x, int n), void *data);
+`
+
+func TestRoutinesCache(t *testing.T) {
+	dir := t.TempDir()
+	header := filepath.Join(dir, "mkl.h")
+	if err := os.WriteFile(header, []byte(sparseHeader), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "list.txt"), []byte("cblas_*scal\nmkl_sparse_d_check\nmkl_set_callback\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previousPath, previousCache, previousDir, previousInputs, previousOutput := mklPath, headerCache, headerCacheDir, inputFuncsPaths, outputFile
+	previousFilter, previousFlags := prefilter, outputFlags
+	mklPath, headerCache, headerCacheDir = header, true, filepath.Join(dir, "cache")
+	inputFuncsPaths = []string{filepath.Join(dir, "list.txt")}
+	t.Cleanup(func() {
+		mklPath, headerCache, headerCacheDir, inputFuncsPaths, outputFile = previousPath, previousCache, previousDir, previousInputs, previousOutput
+		prefilter, outputFlags = previousFilter, previousFlags
+		resetRoutineState()
+		clear(parsedHeaders)
+	})
+
+	// generated is the output of lang generated without the headers parsed before, and whether the header is translated for it
+	generated := func(lang *bool, output string) ([]byte, bool) {
+		resetRoutineState()
+		clear(parsedHeaders)
+		*lang, outputFile = true, filepath.Join(dir, output)
+		// the flags of the outputs are part of the key, as recorded from the command line
+		outputFlags = []string{"--output=" + output}
+		defer func() { *lang = false }()
+		files := generate()
+
+		return files[0].content, len(parsedHeaders) > 0
+	}
+	// the enums, the structs and the function pointers are read from the cache as the routines taking them
+	outputs := []struct {
+		lang   *bool
+		output string
+		want   []string
+	}{
+		{&forGo, "mkl.go", []string{"callback *[0]byte", "type CBLAS_LAYOUT int32"}},
+		{&forJulia, "mkl.jl", []string{"struct matrix_descr"}},
+		{&forLua, "mkl.lua", []string{"struct matrix_descr { CBLAS_LAYOUT layout; int mode; };"}},
+	}
+	for _, prefilter = range []bool{true, false} {
+		for _, o := range outputs {
+			cold, translated := generated(o.lang, o.output)
+			if !translated {
+				t.Fatalf("the header is not translated for %s with an empty cache, with --prefilter=%v", o.output, prefilter)
+			}
+			warm, translated := generated(o.lang, o.output)
+			if translated {
+				t.Errorf("the header is translated again for %s with the routines cached, with --prefilter=%v", o.output, prefilter)
+			}
+			if !bytes.Equal(cold, warm) {
+				t.Errorf("%s from the cache differs, with --prefilter=%v:\n%s\nwant\n%s", o.output, prefilter, warm, cold)
+			}
+			for _, want := range o.want {
+				if !bytes.Contains(warm, []byte(want)) {
+					t.Errorf("%s from the cache has no %s, with --prefilter=%v", o.output, want, prefilter)
+				}
+			}
+		}
+	}
+
+	// the routines of the header changed since are read from it again
+	if err := os.WriteFile(header, []byte(sparseHeader+"void cblas_hscal(const int N, const float alpha, float *X, const int incX);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, translated := generated(&forLua, "mkl.lua"); !translated {
+		t.Error("the header changed since it was cached is not translated")
+	}
+}
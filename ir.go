@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// irOutput is the routines and the constants of the function list as read from the header, which the ir subcommand
+// writes as json for other tools to generate their bindings from.
+type irOutput struct {
+	Routines  []irRoutine `json:"routines"`
+	Constants []irConst   `json:"constants"`
+	Enums     []irEnum    `json:"enums"`
+	Structs   []irStruct  `json:"structs"`
+}
+
+type irRoutine struct {
+	Name string `json:"name"`
+	// Wrapper is the name the routine is generated under, such as cblas_gemm for cblas_dgemm.
+	Wrapper string `json:"wrapper"`
+	// Type is the element type of the routine, such as f64, which is empty for the plain routines.
+	Type string `json:"type,omitempty"`
+	// Output is the element type of the output of the mixed precision routines.
+	Output     string    `json:"output,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	Return     string    `json:"return"`
+	Params     []irParam `json:"params"`
+	Variadic   bool      `json:"variadic,omitempty"`
+	Deprecated bool      `json:"deprecated,omitempty"`
+	// UnsafeOnly is true for the routines of the entries with unsafe-only, which have no safe wrappers.
+	UnsafeOnly bool `json:"unsafe_only,omitempty"`
+	// Declaration is the declaration in the header after preprocessing.
+	Declaration string `json:"declaration"`
+}
+
+type irParam struct {
+	Name string `json:"name"`
+	// Type is the c type with the typedefs of the arithmetic types resolved, such as int for MKL_INT.
+	Type string `json:"type"`
+	// Declared is the c type as declared in the header.
+	Declared string `json:"declared"`
+	Extent   string `json:"extent,omitempty"`
+}
+
+type irConst struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value int64  `json:"value"`
+}
+
+type irEnum struct {
+	Name   string    `json:"name"`
+	Values []irConst `json:"values"`
+}
+
+// irStruct is a struct the routines take by value, such as struct matrix_descr.
+type irStruct struct {
+	Name   string    `json:"name"`
+	Fields []irParam `json:"fields"`
+}
+
+// elemName is the name of elem in --wildcards and only:, such as f64, or empty for the plain routines.
+func elemName(elem elemType) string {
+	for name, e := range elemNames {
+		if e == elem {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// newIROutput is the ir of the routines and the constants of i.
+func newIROutput(i *tmplInput) *irOutput {
+	r := &irOutput{Routines: []irRoutine{}, Constants: []irConst{}, Enums: []irEnum{}, Structs: []irStruct{}}
+	for _, f := range i.funcDefs {
+		routine := irRoutine{
+			Name:        f.RawName,
+			Wrapper:     f.BetterName,
+			Type:        elemName(f.elem),
+			Group:       f.group,
+			Return:      f.ReturnType,
+			Params:      irParams(f.args),
+			Variadic:    f.variadic,
+			Deprecated:  f.deprecated,
+			UnsafeOnly:  f.unsafeOnly,
+			Declaration: f.Declaration,
+		}
+		if f.out != f.elem {
+			routine.Output = elemName(f.out)
+		}
+		r.Routines = append(r.Routines, routine)
+	}
+
+	for _, g := range i.VSLConstants {
+		for _, c := range g.Consts {
+			r.Constants = append(r.Constants, irConst{Name: c.Name, Type: g.TypeName, Value: c.Value})
+		}
+	}
+
+	for _, e := range i.Enums {
+		enum := irEnum{Name: e.Name, Values: []irConst{}}
+		for _, c := range e.Consts {
+			enum.Values = append(enum.Values, irConst{Name: c.Name, Type: e.Name, Value: c.Value})
+		}
+		r.Enums = append(r.Enums, enum)
+	}
+
+	for _, st := range i.Structs {
+		r.Structs = append(r.Structs, irStruct{Name: st.Name, Fields: irParams(st.fields)})
+	}
+
+	return r
+}
+
+func irParams(args []funcArg) []irParam {
+	r := make([]irParam, 0, len(args))
+	for _, p := range args {
+		r = append(r, irParam{Name: p.name, Type: p.typeName, Declared: p.declType, Extent: p.extent})
+	}
+
+	return r
+}
+
+// runIR writes the ir of the function list to the output, or stdout without --output or with -o -.
+func runIR(cmd *cobra.Command, args []string) {
+	_, input := loadRoutines()
+	b := getOrPanic(json.MarshalIndent(newIROutput(input), "", "  "))
+	b = append(b, '\n')
+
+	if outputFile == "" || outputFile == "-" {
+		_, err := os.Stdout.Write(b)
+		orFail(exitOutput, err)
+		return
+	}
+	orFail(exitOutput, os.WriteFile(outputFile, b, 0o666))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//go:embed java.tmpl
+var javaTmplText string
+
+var (
+	forJava         = false
+	javaPackageName = "mklroutines"
+	javaLibrary     = "mkl_rt"
+)
+
+func (*tmplInput) JavaPackageName() string {
+	return javaPackageName
+}
+
+func (*tmplInput) JavaLibrary() string {
+	return javaLibrary
+}
+
+// getJavaParamType returns the java type and the value layout of c type t, and if the type is the dispatched float type.
+// self is the java type used for the float type.
+// Types that are not known are passed as addresses.
+func getJavaParamType(t string, self string, selfLayout string) (string, string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.javaType, integer.javaLayout, false
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return "MemorySegment", "ADDRESS", true
+	case "double *", "float *", "float[]", "double[]":
+		return "MemorySegment", "ADDRESS", true
+	case "double", "float", "const double", "const float":
+		return self, selfLayout, true
+	case "char", "const char":
+		return "byte", "JAVA_BYTE", false
+	case "int *", "const int *", "int64_t *", "const int64_t *":
+		return "MemorySegment", "ADDRESS", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "int", "JAVA_INT", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "int", "JAVA_INT", false
+	case isStruct(t):
+		// passed by value as a segment laid out as the struct
+		return "MemorySegment", structNames[structTag(t)].JavaLayoutName(), false
+	}
+
+	return "MemorySegment", "ADDRESS", false
+}
+
+// JavaLayoutName is the name of the constant with the layout of the struct, such as MATRIX_DESCR.
+func (s *structDef) JavaLayoutName() string {
+	return strings.ToUpper(s.Name)
+}
+
+// JavaLayout is the layout of the struct.
+func (s *structDef) JavaLayout() string {
+	r := []string{}
+	for _, field := range s.fields {
+		_, l, _ := getJavaParamType(field.typeName, fieldSelf(field, "double", "float"), fieldSelf(field, "JAVA_DOUBLE", "JAVA_FLOAT"))
+		r = append(r, fmt.Sprintf("%s.withName(\"%s\")", l, field.name))
+	}
+
+	return fmt.Sprintf("MemoryLayout.structLayout(%s)", strings.Join(r, ", "))
+}
+
+// JavaBoxed is the boxed java type of the float type, which is the type argument of the interface.
+func (f *funcDef) JavaBoxed() string {
+	if f.is32() {
+		return "Float"
+	}
+	return "Double"
+}
+
+// JavaPrimitive is the primitive java type of the float type.
+func (f *funcDef) JavaPrimitive() string {
+	if f.is32() {
+		return "float"
+	}
+	return "double"
+}
+
+func (f *funcDef) javaSelfLayout() string {
+	if f.is32() {
+		return "JAVA_FLOAT"
+	}
+	return "JAVA_DOUBLE"
+}
+
+// JavaParams are the parameters of the java method, with self as the type of float scalars.
+func (f *funcDef) JavaParams(self string) string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _, _ := getJavaParamType(p.typeName, self, f.javaSelfLayout())
+		r = append(r, fmt.Sprintf("%s %s", t, p.name))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// JavaReturn is the return type of the java method, with self as the type of float.
+func (f *funcDef) JavaReturn(self string) string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.javaType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return "void"
+	case "float", "double":
+		return self
+	}
+	if isEnum(f.ReturnType) {
+		return "int"
+	}
+
+	return "MemorySegment"
+}
+
+func (f *funcDef) javaReturnLayout() string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.javaLayout
+	}
+
+	switch f.ReturnType {
+	case "float", "double":
+		return f.javaSelfLayout()
+	}
+	if isEnum(f.ReturnType) {
+		return "JAVA_INT"
+	}
+	if isStruct(f.ReturnType) {
+		return structNames[structTag(f.ReturnType)].JavaLayoutName()
+	}
+
+	return "ADDRESS"
+}
+
+// JavaDescriptor is the FunctionDescriptor of the routine.
+func (f *funcDef) JavaDescriptor() string {
+	layouts := []string{}
+	for _, p := range f.args {
+		_, l, _ := getJavaParamType(p.typeName, f.JavaPrimitive(), f.javaSelfLayout())
+		layouts = append(layouts, l)
+	}
+
+	if !f.HasReturn() {
+		return fmt.Sprintf("FunctionDescriptor.ofVoid(%s)", strings.Join(layouts, ", "))
+	}
+
+	return fmt.Sprintf("FunctionDescriptor.of(%s)", strings.Join(append([]string{f.javaReturnLayout()}, layouts...), ", "))
+}
+
+// JavaReturnsStruct is true if any of the routines returns a struct by value, such as MKL_Complex16 of cblas_zdotu.
+func (i *tmplInput) JavaReturnsStruct() bool {
+	return slices.ContainsFunc(i.funcDefs, func(f funcDef) bool { return isStruct(f.ReturnType) })
+}
+
+// JavaInvoke is the invokeExact call of the routine's method handle, casting the float scalars to primitives.
+func (f *funcDef) JavaInvoke() string {
+	args := []string{}
+	for _, p := range f.args {
+		_, _, isSelf := getJavaParamType(p.typeName, f.JavaPrimitive(), f.javaSelfLayout())
+		if isSelf && !strings.HasSuffix(p.typeName, "*") && !strings.HasSuffix(p.typeName, "[]") {
+			args = append(args, fmt.Sprintf("(%s) %s", f.JavaPrimitive(), p.name))
+		} else {
+			args = append(args, p.name)
+		}
+	}
+	// the handles of the routines returning structs take the allocator of the returned segment first
+	if isStruct(f.ReturnType) {
+		args = append([]string{"(SegmentAllocator) Arena.ofAuto()"}, args...)
+	}
+
+	call := fmt.Sprintf("Handles.%s.invokeExact(%s)", f.RawName, strings.Join(args, ", "))
+	if !f.HasReturn() {
+		return call
+	}
+
+	return fmt.Sprintf("return (%s) %s", f.JavaReturn(f.JavaPrimitive()), call)
+}
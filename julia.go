@@ -0,0 +1,222 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//go:embed julia.tmpl
+var juliaTmplText string
+
+var (
+	forJulia        = false
+	juliaModuleName = "MKLRoutines"
+	juliaLibrary    = "libmkl_rt"
+)
+
+func (*tmplInput) JuliaModuleName() string {
+	return juliaModuleName
+}
+
+func (*tmplInput) JuliaLibrary() string {
+	return juliaLibrary
+}
+
+// JuliaSelf is the julia type the routine is dispatched on, ComplexF32 and ComplexF64 for the complex routines,
+// which have the layout of MKL_Complex8 and MKL_Complex16.
+func (f *funcDef) JuliaSelf() string {
+	switch {
+	case f.elem == complex64Elem:
+		return "ComplexF32"
+	case f.elem == complex128Elem:
+		return "ComplexF64"
+	case f.is32():
+		return "Float32"
+	}
+	return "Float64"
+}
+
+// juliaParamType is the type used in ccall for the c type t in the routine, and if it is the dispatched type.
+// The complex routines take their complex type by the void pointers of cblas as well, as the rust output does,
+// and the float types they take, such as the alpha of cblas_csscal, are not dispatched on.
+func (f *funcDef) juliaParamType(t string) (string, bool) {
+	if !f.isComplex() {
+		return getJuliaParamType(t, f.JuliaSelf())
+	}
+
+	base, depth := strings.TrimSuffix(t, "[]"), 0
+	if base != t {
+		depth++
+	}
+	for {
+		pointee, _, isPointer := cutPointer(base)
+		if !isPointer {
+			break
+		}
+		base, depth = pointee, depth+1
+	}
+	base = strings.TrimPrefix(base, "const ")
+
+	r, isSelf := "", false
+	switch {
+	case base == f.elem.cType(), base == "void" && depth > 0:
+		r, isSelf = f.JuliaSelf(), depth <= 1
+	case base == "float":
+		r = "Float32"
+	case base == "double":
+		r = "Float64"
+	default:
+		return getJuliaParamType(t, f.JuliaSelf())
+	}
+	for ; depth > 0; depth-- {
+		r = fmt.Sprintf("Ptr{%s}", r)
+	}
+
+	return r, isSelf
+}
+
+// getJuliaParamType returns the type used in ccall for the c type t, and if the type is the dispatched float type.
+func getJuliaParamType(t string, self string) (string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.juliaType, false
+	}
+
+	if _, isFunc := funcPointerTypes[t]; isFunc {
+		return "Ptr{Cvoid}", false
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return fmt.Sprintf("Ptr{%s}", self), true
+	case "double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("Ptr{%s}", self), true
+	case "double", "float", "const double", "const float":
+		return self, true
+	case "char", "const char":
+		return "Cchar", false
+	case "MKL_Complex8", "const MKL_Complex8":
+		return "ComplexF32", self == "ComplexF32"
+	case "MKL_Complex16", "const MKL_Complex16":
+		return "ComplexF64", self == "ComplexF64"
+	case "int *", "const int *":
+		return "Ptr{Cint}", false
+	case "int64_t *", "const int64_t *":
+		return "Ptr{Int64}", false
+	case "void *", "const void *":
+		return "Ptr{Cvoid}", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "Cint", false
+	case "sparse_matrix_t", "const sparse_matrix_t", "VSLStreamStatePtr", "const VSLStreamStatePtr",
+		"DFTI_DESCRIPTOR_HANDLE", "const DFTI_DESCRIPTOR_HANDLE":
+		return "Ptr{Cvoid}", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "Cint", false
+	case isStruct(t):
+		return structTag(t), false
+	}
+
+	// pointer to pointer, such as the arrays of the batch routines
+	if pointee, _, isPointer := cutPointer(t); isPointer {
+		r, _ := getJuliaParamType(pointee, self)
+		return fmt.Sprintf("Ptr{%s}", r), false
+	}
+
+	return strings.TrimPrefix(t, "const "), false
+}
+
+// JuliaParams are the parameters of the julia method. Only the parameters of the dispatched type are annotated,
+// the rest are converted by ccall.
+func (f *funcDef) JuliaParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, isSelf := f.juliaParamType(p.typeName)
+		switch {
+		case isSelf && strings.HasPrefix(t, "Ptr{"):
+			r = append(r, fmt.Sprintf("%s::PtrOrArray{%s}", p.name, f.JuliaSelf()))
+		case isSelf:
+			r = append(r, fmt.Sprintf("%s::%s", p.name, t))
+		default:
+			r = append(r, p.name)
+		}
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// JuliaArgTypes is the tuple of argument types for ccall.
+func (f *funcDef) JuliaArgTypes() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := f.juliaParamType(p.typeName)
+		r = append(r, t)
+	}
+
+	if len(r) == 1 {
+		return fmt.Sprintf("(%s,)", r[0])
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(r, ", "))
+}
+
+// JuliaReturn is the return type of the routine in ccall.
+func (f *funcDef) JuliaReturn() string {
+	if f.ReturnType == "void" {
+		return "Cvoid"
+	}
+	t, _ := f.juliaParamType(f.ReturnType)
+	return t
+}
+
+// JuliaStructs are the structs declared in julia, leaving out the complex types, which are ComplexF32 and ComplexF64.
+func (i *tmplInput) JuliaStructs() []*structDef {
+	r := []*structDef{}
+	for _, s := range i.Structs {
+		if _, isComplex := complexFields[s.Name]; !isComplex {
+			r = append(r, s)
+		}
+	}
+
+	return r
+}
+
+// JuliaFields are the fields of the julia struct, which has the same layout as the c struct.
+func (s *structDef) JuliaFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		t, _ := getJuliaParamType(field.typeName, "Float64")
+		r = append(r, fmt.Sprintf("%s::%s", field.name, t))
+	}
+
+	return r
+}
+
+// JuliaComplexFuncs are the complex routines, with the ones on MKL_Complex8 only when they take it,
+// as the others would be the same methods as the ones on MKL_Complex16.
+func (i *tmplInput) JuliaComplexFuncs() []*funcDef {
+	r := i.C128Funcs()
+	for _, f := range i.C64Funcs() {
+		if f.TakesElem() {
+			r = append(r, f)
+		}
+	}
+
+	return r
+}
+
+// JuliaExports are the names exported from the julia module, each once, or empty if there is none.
+func (i *tmplInput) JuliaExports() string {
+	names := []string{}
+	for _, f := range slices.Concat(i.TraitFuncs(), i.C64Funcs(), i.PlainFuncs()) {
+		if !slices.Contains(names, f.BetterName) {
+			names = append(names, f.BetterName)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
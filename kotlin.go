@@ -0,0 +1,138 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed kotlin.tmpl
+var kotlinTmplText string
+
+var (
+	forKotlin         = false
+	kotlinPackageName = "mklroutines"
+	kotlinDefFile     = ""
+	kotlinLinkerOpts  = "-lmkl_rt"
+)
+
+func (*tmplInput) KotlinPackageName() string {
+	return kotlinPackageName
+}
+
+func (*tmplInput) KotlinLinkerOpts() string {
+	return kotlinLinkerOpts
+}
+
+// getKotlinDefPath is where the cinterop def file is written. By default it is next to the kotlin output.
+func getKotlinDefPath(output string) string {
+	if kotlinDefFile != "" {
+		return kotlinDefFile
+	}
+
+	return strings.TrimSuffix(output, ".kt") + ".def"
+}
+
+// getKotlinParamType returns the kotlin type of c type t as generated by cinterop.
+// self is the type of float scalars and selfVar the type of float arrays.
+// Types that are not known are referred to by their c names, which are generated by cinterop from the def file.
+func getKotlinParamType(t string, self string, selfVar string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.kotlinType
+	}
+
+	// the parameters of CFunction are CPointer instead of CValuesRef
+	if fn, isFunc := funcPointerTypes[t]; isFunc {
+		args := []string{}
+		for _, arg := range fn.args {
+			args = append(args, strings.Replace(getKotlinParamType(arg.typeName, self, selfVar), "CValuesRef<", "CPointer<", 1))
+		}
+		ret := "Unit"
+		if fn.returnType != "void" {
+			ret = getKotlinParamType(fn.returnType, self, selfVar)
+		}
+		return fmt.Sprintf("CPointer<CFunction<(%s) -> %s>>?", strings.Join(args, ", "), ret)
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("CValuesRef<%s>?", selfVar)
+	case "double", "float", "const double", "const float":
+		return self
+	case "char", "const char":
+		return "Byte"
+	case "int *", "const int *":
+		return "CValuesRef<IntVar>?"
+	case "int64_t *", "const int64_t *":
+		return "CValuesRef<LongVar>?"
+	case "void *", "const void *":
+		return "CValuesRef<*>?"
+	}
+
+	// pointer to pointer, such as the arrays of the batch routines
+	if pointee, _, isPointer := cutPointer(t); isPointer && strings.HasSuffix(pointee, "*") {
+		inner := getKotlinParamType(pointee, self, selfVar)
+		if inner == "CValuesRef<*>?" {
+			return "CValuesRef<COpaquePointerVar>?"
+		}
+		// such as CValuesRef<CPointerVar<IntVar>>? for the MKL_INT ** of mkl_sparse_?_export_csr
+		return fmt.Sprintf("CValuesRef<CPointerVar<%s>>?", strings.TrimSuffix(strings.TrimPrefix(inner, "CValuesRef<"), ">?"))
+	}
+
+	// cinterop passes the structs by value as CValue, such as CValue<matrix_descr>
+	if isStruct(t) {
+		return fmt.Sprintf("CValue<%s>", structTag(t))
+	}
+
+	// cinterop names the variable type of a c type with Var suffix
+	if pointee, _, isPointer := cutPointer(t); isPointer {
+		return fmt.Sprintf("CValuesRef<%sVar>?", getKotlinParamType(pointee, self, selfVar))
+	}
+
+	return strings.TrimPrefix(t, "const ")
+}
+
+// KotlinSelf is the kotlin type of the float type.
+func (f *funcDef) KotlinSelf() string {
+	if f.is32() {
+		return "Float"
+	}
+	return "Double"
+}
+
+// KotlinSelfVar is the cinterop variable type of the float type.
+func (f *funcDef) KotlinSelfVar() string {
+	return f.KotlinSelf() + "Var"
+}
+
+// KotlinParams are the parameters of the routine, with self as the float type and selfVar as the float variable type.
+func (f *funcDef) KotlinParams(self string, selfVar string) string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, fmt.Sprintf("%s: %s", p.name, getKotlinParamType(p.typeName, self, selfVar)))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// KotlinReturn is the return type of the routine, with self as the float type.
+func (f *funcDef) KotlinReturn(self string) string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.kotlinType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return "Unit"
+	case "float", "double":
+		return self
+	case "void *":
+		return "COpaquePointer?"
+	}
+	if isStruct(f.ReturnType) {
+		return fmt.Sprintf("CValue<%s>", structTag(f.ReturnType))
+	}
+
+	return f.ReturnType
+}
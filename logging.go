@@ -0,0 +1,31 @@
+package main
+
+import "log"
+
+var (
+	// verbosity is the number of -v, which logs the progress with 1, and each routine with 2.
+	verbosity = 0
+	// quiet drops the warnings, leaving only the failures.
+	quiet = false
+)
+
+// warnf logs what may not be generated as expected, such as the deprecated routines, unless --quiet.
+func warnf(format string, v ...any) {
+	if !quiet {
+		log.Printf(format, v...)
+	}
+}
+
+// infof logs the progress, such as reading the header, with -v.
+func infof(format string, v ...any) {
+	if verbosity >= 1 && !quiet {
+		log.Printf(format, v...)
+	}
+}
+
+// debugf logs the routines as they are selected and the types they fall back to, with -vv.
+func debugf(format string, v ...any) {
+	if verbosity >= 2 && !quiet {
+		log.Printf(format, v...)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	_ "embed"
+)
+
+//go:embed lua.tmpl
+var luaTmplText string
+
+var (
+	forLua     = false
+	luaLibrary = "mkl_rt"
+)
+
+func (*tmplInput) LuaLibrary() string {
+	return luaLibrary
+}
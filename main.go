@@ -45,14 +45,16 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 	"modernc.org/cc/v4"
-	"mvdan.cc/gofumpt/format"
 )
 
 //go:embed rs.tmpl
@@ -66,7 +68,7 @@ var goTmplText string
 
 var (
 	mklPath          = ""
-	inputFuncsPath   = ""
+	inputFuncsPaths  []string
 	outputFile       = ""
 	mklProviderCrate = "crate"
 	traitName        = "MKLRoutines"
@@ -74,11 +76,20 @@ var (
 	forGo            = false
 	goPackageName    = "mklroutines"
 	cMacroDefines    = "MKL_ROUNTINES_WARPPERS_H_"
+	ilp64            = false
+	ilp64Symbols     = false
+	// fortranSymbols selects the fortran symbols of the routines, such as dgemm_ for *gemm, which take all the scalars by pointer.
+	fortranSymbols      = false
+	fortranSymbolSuffix = "_"
 )
 
 type funcArg struct {
 	name     string
 	typeName string
+	// declType is the type as declared in the header, before the typedefs are resolved
+	declType string
+	// extent is the size of an array parameter, such as 4 for const float a[4]. It is empty for a[] and the pointers.
+	extent string
 	// rustName is the rust type name
 	rustName string
 	// dontUse indicates if the type should be imported from crate, for rust
@@ -87,14 +98,105 @@ type funcArg struct {
 	cgoType string
 	// goType
 	goType string
+	// fn is the function type if the parameter is a function pointer.
+	fn *funcPointer
+}
+
+// elemType is the element type a routine operates on, which is Self in the generated code.
+type elemType int
+
+const (
+	float64Elem elemType = iota
+	float32Elem
+	complex128Elem
+	complex64Elem
+	// float16Elem is MKL_F16, selected with the ^ wildcard.
+	float16Elem
+	// bfloat16Elem is MKL_BF16, selected with the @ wildcard.
+	bfloat16Elem
+	// int8Elem, int16Elem and int32Elem are the integer types of the mixed precision routines, selected with the & wildcard.
+	int8Elem
+	int16Elem
+	int32Elem
+	// noElem is for the routines that take no element type, such as vslNewStream, which are generated as plain functions.
+	noElem
+)
+
+// cType is the c type of the element, which becomes Self.
+func (e elemType) cType() string {
+	switch e {
+	case float32Elem:
+		return "float"
+	case complex128Elem:
+		return "MKL_Complex16"
+	case complex64Elem:
+		return "MKL_Complex8"
+	case float16Elem:
+		return "MKL_F16"
+	case bfloat16Elem:
+		return "MKL_BF16"
+	case int8Elem:
+		return "MKL_INT8"
+	case int16Elem:
+		return "MKL_INT16"
+	case int32Elem:
+		return "MKL_INT32"
+	case noElem:
+		return ""
+	default:
+		return "double"
+	}
 }
 
 type funcDef struct {
-	RawName    string
-	is32       bool
+	RawName string
+	elem    elemType
+	// out is the element type of the output, which differs from elem for the mixed precision routines.
+	out        elemType
 	ReturnType string
 	args       []funcArg
 	BetterName string
+	// Declaration is the declaration of the function as in the header, after preprocessing.
+	Declaration string
+	// declReturnType is the return type as declared in the header, before the typedefs are resolved, such as MKL_Complex16.
+	declReturnType string
+	// header and line are where the header declares the function.
+	header string
+	line   int
+	// deprecated is true when the declaration is marked with MKL_DEPRECATED.
+	deprecated bool
+	// variadic is true when the routine takes ... after args, such as DftiComputeForward, which is called with args only.
+	variadic bool
+	// group is the group of the routine in the function list, which the rust, go and c++ outputs are split by.
+	group string
+	// renamed is true when the entry selecting the routine names its wrappers with = name.
+	renamed bool
+	// unsafeOnly is true when the entry selecting the routine has unsafe-only, which rust generates no safe wrapper for.
+	unsafeOnly bool
+}
+
+// is32 is true for routines on float32, and for the plain routines taking float but not double,
+// such as cblas_sdot listed without wildcards.
+func (f *funcDef) is32() bool {
+	if f.elem == noElem {
+		return f.takesCType("float") && !f.takesCType("double")
+	}
+
+	return f.elem == float32Elem
+}
+
+// takesCType is true if the routine takes or returns c type t, or pointers to it.
+func (f *funcDef) takesCType(t string) bool {
+	if cBaseType(f.ReturnType) == t {
+		return true
+	}
+	for _, p := range f.args {
+		if cBaseType(p.typeName) == t {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (f *funcDef) HasReturn() bool {
@@ -109,11 +211,86 @@ func (f *funcDef) GoName() string {
 	return string(name)
 }
 
+// lowerLeading lower cases the leading upper case letters, for languages where functions must start with a lower case letter.
+// For example, LAPACKE_potrf becomes lapacke_potrf.
+func lowerLeading(name string) string {
+	b := []byte(name)
+	for i := range b {
+		if b[i] < 'A' || b[i] > 'Z' {
+			break
+		}
+		b[i] = 'a' + (b[i] - 'A')
+	}
+
+	return string(b)
+}
+
 type tmplInput struct {
-	funcDefs        []funcDef
+	funcDefs []funcDef
+	// unsafeOnly are the routines of the entries with unsafe-only, which the rust output has no safe wrappers for.
+	unsafeOnly      []funcDef
 	providerCrate   string
 	DesiredFuncList []string
 	Includes        []string
+	// VSLConstants are the VSL_BRNG_* and VSL_RNG_METHOD_* constants, only populated when RNG routines are selected,
+	// and the constants in the function list.
+	VSLConstants []*constGroup
+	// Enums are the enums the routines take, such as CBLAS_LAYOUT.
+	Enums []*enumDef
+	// Structs are the structs the routines take by value, such as struct matrix_descr.
+	Structs []*structDef
+	// typedefs are the typedefs the routines depend on, in the order they are declared in the header.
+	typedefs []string
+	// goCblasEnums is true if the header declares the enumerators of the cblas enums, such as CblasRowMajor.
+	goCblasEnums bool
+	// headerVersion is the header in the banner, with the version of mkl or openblas it declares.
+	headerVersion string
+	// banner is the lines of the comment at the top of the outputs, recording how they are generated.
+	banner []string
+	// group is the group of the routines of the output, which is empty for the routines in no group.
+	group string
+	// groups are the outputs of the groups of the function list, when the output is split by the groups.
+	groups []*tmplInput
+}
+
+// Group is the name of the group of the routines of the output, such as blas for the routines after [group blas].
+func (i *tmplInput) Group() string {
+	return i.group
+}
+
+// Groups are the outputs of the groups with routines, in the order of the function list,
+// which are empty unless the output is split by the groups.
+func (i *tmplInput) Groups() []*tmplInput {
+	return i.groups
+}
+
+// splitGroups moves the routines of each of groups into an output of its own.
+func (i *tmplInput) splitGroups(groups []string) {
+	for _, group := range groups {
+		if !slices.ContainsFunc(i.funcDefs, func(f funcDef) bool { return f.group == group }) {
+			continue
+		}
+		g := *i
+		g.group = group
+		g.groups = nil
+		i.groups = append(i.groups, &g)
+	}
+}
+
+// groupFuncs are the routines of the group of the output, or all the routines if the output is not split by the groups.
+func (i *tmplInput) groupFuncs() []funcDef {
+	if i.group == "" && len(i.groups) == 0 {
+		return i.funcDefs
+	}
+
+	r := []funcDef{}
+	for _, f := range i.funcDefs {
+		if f.group == i.group {
+			r = append(r, f)
+		}
+	}
+
+	return r
 }
 
 func (*tmplInput) TraitName() string {
@@ -124,18 +301,50 @@ func (*tmplInput) GoPackageName() string {
 	return goPackageName
 }
 
+// ILP64 is true when the header is translated with MKL_ILP64 defined, and the generated c code must define it too.
+func (*tmplInput) ILP64() bool {
+	return ilp64
+}
+
+// HeaderMacros are the macros the generated c code defines before including the header, the ones of accelerate with --accelerate,
+// or of the blas other than mkl, such as the platform of hip, and otherwise with --ilp64, MKL_ILP64 for mkl,
+// or the ones of openblas and netlib for the other headers, such as cblas.h.
+func (*tmplInput) HeaderMacros() []string {
+	switch base := filepath.Base(mklPath); {
+	case accelerate:
+		return accelerateMacros()
+	case selectedProvider() != nil:
+		return selectedProvider().macros
+	case !ilp64:
+		return nil
+	case base == "mkl.h" || strings.HasPrefix(base, "mkl_"):
+		return ilp64Macros[:1]
+	default:
+		return ilp64Macros[1:]
+	}
+}
+
 func (*tmplInput) CMacroDefines() string {
 	return cMacroDefines
 }
 
 func (f *funcDef) CParams() string {
+	return f.cParams(func(p funcArg) string { return p.declType })
+}
+
+// cParams are the parameters of the routine with their types from convert.
+func (f *funcDef) cParams(convert func(funcArg) string) string {
 	ps := []string{}
 
 	for _, p := range f.args {
-		if strings.HasSuffix(p.typeName, "[]") {
-			ps = append(ps, fmt.Sprintf("%s %s[]", strings.TrimSuffix(p.typeName, "[]"), p.name))
-		} else {
-			ps = append(ps, fmt.Sprintf("%s %s", p.typeName, p.name))
+		t := convert(p)
+		switch {
+		case p.fn != nil:
+			ps = append(ps, p.fn.declare(p.name))
+		case strings.HasSuffix(t, "[]"):
+			ps = append(ps, fmt.Sprintf("%s %s[%s]", strings.TrimSuffix(t, "[]"), p.name, p.extent))
+		default:
+			ps = append(ps, fmt.Sprintf("%s %s", t, p.name))
 		}
 	}
 
@@ -151,16 +360,33 @@ func (f *funcDef) CInput() string {
 	return strings.Join(ps, ",")
 }
 
+// ProviderCrate is the crate or module providing the c bindings.
+func (i *tmplInput) ProviderCrate() string {
+	return i.providerCrate
+}
+
 func (i *tmplInput) UseLine() string {
 	uses := make([]string, 0, len(i.funcDefs)+3)
 
 	blastypes := make(map[string]struct{})
 
 	for _, f := range i.funcDefs {
-		uses = append(uses, f.RawName)
+		// the plain functions are called by their paths, since the wrappers have the same names.
+		if f.elem != noElem {
+			uses = append(uses, f.RawName)
+		}
 		for _, arg := range f.args {
-			if !arg.dontUse {
-				blastypes[arg.rustName] = struct{}{}
+			if rustName, dontUse := f.rustParamType(arg.typeName); !dontUse && !isRustEnum(rustName) {
+				blastypes[rustPointee(rustName)] = struct{}{}
+			}
+		}
+		// the types of the precision are the associated types of the implementations, such as fftw_plan = fftwf_plan
+		for _, a := range assocTypes([]*funcDef{&f}) {
+			blastypes[a.Type] = struct{}{}
+		}
+		if f.HasReturn() {
+			if rustName, dontUse := f.rustParamType(f.ReturnType); !dontUse && !isRustEnum(rustName) {
+				blastypes[rustPointee(rustName)] = struct{}{}
 			}
 		}
 	}
@@ -177,10 +403,14 @@ func (i *tmplInput) UseLine() string {
 type GoFuncPair struct {
 	Float64Func *funcDef
 	Float32Func *funcDef
-	Name        string
+	// Complex128Func and Complex64Func are only set for go, where the complex routines can be dispatched with the real ones.
+	Complex128Func *funcDef
+	Complex64Func  *funcDef
+	Name           string
 }
 
-func (i *tmplInput) GoFuncs() []*GoFuncPair {
+// FuncPairs pairs up the float32 and float64 routines by their better names.
+func (i *tmplInput) FuncPairs() []*GoFuncPair {
 	f32funcs := i.F32Funcs()
 	result := make([]*GoFuncPair, 0, len(f32funcs))
 	byname := make(map[string]*GoFuncPair)
@@ -197,7 +427,7 @@ func (i *tmplInput) GoFuncs() []*GoFuncPair {
 	for _, f64func := range i.F64Funcs() {
 		f, ok := byname[f64func.GoName()]
 		if !ok {
-			panic(fmt.Sprintf("f64 has name %s", f64func.GoName()))
+			failf(exitTemplate, "f64 has name %s, which has no f32 routine to pair with", f64func.GoName())
 		}
 		f.Float64Func = f64func
 	}
@@ -205,52 +435,114 @@ func (i *tmplInput) GoFuncs() []*GoFuncPair {
 	return result
 }
 
+// dispatchArg is the parameter that decides which precision is called when dispatching at runtime or with macros:
+// the first float array, or the first float scalar if the routine takes no arrays.
+// It is nil when the routine takes neither.
+func (f *GoFuncPair) dispatchArg() (arg *funcArg, isArray bool) {
+	for i, p := range f.Float32Func.args {
+		t, _ := getRustParamType(p.typeName)
+		if t == "*const Self" || t == "*mut Self" {
+			return &f.Float32Func.args[i], true
+		}
+		if t == "Self" && arg == nil {
+			arg = &f.Float32Func.args[i]
+		}
+	}
+
+	return arg, false
+}
+
 func getGoParamType(t string) string {
+	if mapped, found := lookupType(userTypes.Go, t); found {
+		return mapped
+	}
+
+	// cgo has function pointers as *[0]byte
+	if _, isFunc := funcPointerTypes[t]; isFunc {
+		return "*[0]byte"
+	}
+
+	// the types of the precision, such as fftw_plan and fftwf_plan, are unsafe.Pointer for the generic functions over both
+	if _, isPrecision := precisionTypedef(t); isPrecision {
+		return "unsafe.Pointer"
+	}
+
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.goType
+	}
+
 	switch t {
-	case "size_t":
-		return "uint64"
-	case "int32_t", "int", "const int", "const int32_t":
-		return "int32"
-	case "int64_t":
-		return "int64"
-	case "const double *", "const float *", "const float[]", "const double[]":
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"const MKL_Complex16 *", "const MKL_Complex8 *", "const MKL_Complex16[]", "const MKL_Complex8[]":
 		return "*F"
-	case "double *", "float *", "float[]", "double[]":
+	case "double *", "float *", "float[]", "double[]",
+		"MKL_Complex16 *", "MKL_Complex8 *", "MKL_Complex16[]", "MKL_Complex8[]":
 		return "*F"
-	case "double", "float", "const double", "const float":
+	case "double", "float", "const double", "const float",
+		"MKL_Complex16", "MKL_Complex8", "const MKL_Complex16", "const MKL_Complex8":
 		return "F"
 	case "char":
 		return "byte"
-	case "int *":
-		return "*int32"
-	case "const int *":
-		return "*int32"
 	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE":
 		return t
+	case "void *", "const void *":
+		return "unsafe.Pointer"
+	}
+
+	// pointers, including the pointer to pointer arrays of the batch routines
+	if pointee, _, isPointer := cutPointer(t); isPointer {
+		return "*" + getGoParamType(pointee)
 	}
 
 	if strings.HasPrefix(t, "const ") {
 		t = strings.TrimPrefix(t, "const ")
 	}
 
+	if isGoEnum(t) {
+		return t
+	}
+
+	// cgo names struct matrix_descr as C.struct_matrix_descr, and enum DFTI_CONFIG_VALUE as C.enum_DFTI_CONFIG_VALUE
+	if tag := structTag(t); tag != "" {
+		return "C.struct_" + tag
+	}
+	if _, isTag := enumTags[t]; isTag {
+		return "C.enum_" + t
+	}
+	// cgo names the types of several tokens in one, such as C.ulonglong of unsigned long long
+	if name, isMultiToken := cgoTypeNames[t]; isMultiToken {
+		return "C." + name
+	}
+
 	return fmt.Sprintf("C.%s", t)
 }
 
 func (f *GoFuncPair) Params() []string {
+	return f.first().GoParams()
+}
+
+// GoParams are the go parameters of the routine.
+func (f *funcDef) GoParams() []string {
 	r := []string{}
-	for _, p := range f.Float32Func.args {
-		t := getGoParamType(p.typeName)
-		r = append(r, fmt.Sprintf("%s %s", p.name, t))
+	for _, p := range f.args {
+		t := f.goParamType(p.typeName)
+		if strings.TrimLeft(t, "*") == "F" {
+			t = strings.TrimSuffix(t, "F") + f.goSelf(p.typeName)
+		}
+		if vsl := f.vslParamType(p); vsl != "" {
+			t = vsl
+		}
+		r = append(r, fmt.Sprintf("%s %s", goParamName(p.name), t))
 	}
 
 	return r
 }
 
-func (i *tmplInput) getfuncs(is32 bool) []*funcDef {
+func (i *tmplInput) getfuncs(elem elemType) []*funcDef {
 	r := []*funcDef{}
-	for _, f := range i.funcDefs {
+	for _, f := range i.groupFuncs() {
 		f := f
-		if f.is32 == is32 {
+		if f.elem == elem && f.out == elem {
 			r = append(r, &f)
 		}
 	}
@@ -259,67 +551,197 @@ func (i *tmplInput) getfuncs(is32 bool) []*funcDef {
 }
 
 func (i *tmplInput) F64Funcs() []*funcDef {
-	return i.getfuncs(false)
+	return i.getfuncs(float64Elem)
 }
 
 func (i *tmplInput) F32Funcs() []*funcDef {
-	return i.getfuncs(true)
+	return i.getfuncs(float32Elem)
+}
+
+// C128Funcs are the routines on MKL_Complex16, selected with the % wildcard.
+func (i *tmplInput) C128Funcs() []*funcDef {
+	return i.getfuncs(complex128Elem)
+}
+
+// C64Funcs are the routines on MKL_Complex8, selected with the % wildcard.
+func (i *tmplInput) C64Funcs() []*funcDef {
+	return i.getfuncs(complex64Elem)
+}
+
+func (i *tmplInput) F16Funcs() []*funcDef {
+	return i.getfuncs(float16Elem)
+}
+
+func (i *tmplInput) BF16Funcs() []*funcDef {
+	return i.getfuncs(bfloat16Elem)
+}
+
+// PlainFuncs are the routines that take no element type, such as vslNewStream, which are not dispatched.
+func (i *tmplInput) PlainFuncs() []*funcDef {
+	return i.getfuncs(noElem)
+}
+
+// F32Overloads are the float32 routines that overload the float64 ones of the same better name, which are the ones taking the float type.
+// The others, such as DftiCreateDescriptor_s_1d, have the same parameters as the float64 routines,
+// so the languages overloading by the parameters only have the float64 routines under the better names, as the c11 macros do.
+func (i *tmplInput) F32Overloads() []*funcDef {
+	r := []*funcDef{}
+	for _, f := range i.F32Funcs() {
+		if f.TakesElem() {
+			r = append(r, f)
+		}
+	}
+
+	return r
+}
+
+// TakesElem is true when the float type or a type of the precision, such as fftw_plan, is among the parameters or is the return type of the routine.
+func (f *funcDef) TakesElem() bool {
+	for _, p := range f.args {
+		if t, _ := f.rustParamType(p.typeName); strings.Contains(t, "Self") {
+			return true
+		}
+	}
+
+	return strings.Contains(f.ReturnDeclare(), "Self")
+}
+
+// MixedFuncs are the mixed precision routines taking elem and producing out, selected with the & wildcard.
+func (i *tmplInput) MixedFuncs(elem elemType, out elemType) []*funcDef {
+	r := []*funcDef{}
+	for _, f := range i.groupFuncs() {
+		f := f
+		if f.elem == elem && f.out == out && elem != out {
+			r = append(r, &f)
+		}
+	}
+
+	return r
 }
 
 func (i *tmplInput) TraitFuncs() []*funcDef {
-	return i.getfuncs(true)
+	return i.getfuncs(float32Elem)
 }
 
 func (f *GoFuncPair) GoReturn() string {
-	switch f.Float32Func.ReturnType {
+	return f.first().GoReturn()
+}
+
+// GoReturn is the go type of the return value of the routine.
+func (f *funcDef) GoReturn() string {
+	t, _ := f.goReturnType()
+	return t
+}
+
+// goReturnType is the go type of the return value of the routine, and false if it is the raw cgo type without a mapping.
+func (f *funcDef) goReturnType() (string, bool) {
+	if mapped, found := lookupType(userTypes.Go, f.ReturnType); found {
+		return mapped, true
+	}
+
+	if integer, isInteger := integerTypes[f.ReturnType]; isInteger {
+		return integer.goType, true
+	}
+
+	switch f.ReturnType {
 	case "void":
-		return ""
-	case "int32_t", "int":
-		return "int32"
-	case "float", "double":
-		return "F"
-	case "size_t":
-		return "uint64"
+		return "", true
+	case "float", "double", "MKL_Complex8", "MKL_Complex16":
+		return f.goSelf(f.ReturnType), true
+	case "void *":
+		return "unsafe.Pointer", true
 	default:
-		return f.Float32Func.ReturnType
+		// such as C.sparse_status_t
+		_, isPrecision := precisionTypedef(f.ReturnType)
+		return getGoParamType(f.ReturnType), isEnum(f.ReturnType) || isStruct(f.ReturnType) || isPrecision
 	}
 }
 
 func (f *funcDef) ReturnDeclare() string {
+	t, _ := f.rustReturnType()
+	if t == "" {
+		return ""
+	}
+
+	return "-> " + t
+}
+
+// rustReturnType is the rust type of the return value of the routine, and false if it is the raw c type without a mapping.
+func (f *funcDef) rustReturnType() (string, bool) {
+	if mapped, found := lookupType(userTypes.Rust, f.ReturnType); found {
+		return mapped, true
+	}
+
+	if !f.isReal() && f.HasReturn() {
+		if t, known := f.rustParamType(f.ReturnType); known {
+			return t, true
+		}
+	}
+
+	if integer, isInteger := integerTypes[f.ReturnType]; isInteger {
+		return integer.rustType, true
+	}
+
 	switch f.ReturnType {
 	case "void":
-		return ""
-	case "int32_t", "int":
-		return "-> i32"
-	case "float", "double":
-		return "-> Self"
-	case "size_t":
-		return "-> usize"
+		return "", true
+	case "float", "double", "MKL_Complex8", "MKL_Complex16":
+		return "Self", true
+	case "void *":
+		return "*mut std::ffi::c_void", true
 	default:
-		return f.ReturnType
+		// such as sparse_status_t, imported from the crate
+		t, known := f.rustParamType(f.ReturnType)
+		return t, known || isEnum(f.ReturnType) || isStruct(f.ReturnType)
 	}
 }
 
 func getRustParamType(t string) (string, bool) {
+	if mapped, found := lookupType(userTypes.Rust, t); found {
+		return mapped, true
+	}
+
+	if fn, isFunc := funcPointerTypes[t]; isFunc {
+		return fn.rustType(), true
+	}
+
+	// array parameters are pointers, keeping the const of their elements, such as *const Self for const double A[]
+	if elem, isArray := strings.CutSuffix(t, "[]"); isArray {
+		return getRustParamType(elem + " *")
+	}
+
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.rustType, true
+	}
+
 	switch t {
-	case "size_t":
-		return "usize", true
-	case "int32_t", "int", "const int", "const int32_t":
-		return "i32", true
-	case "int64_t":
-		return "i64", true
-	case "const double *", "const float *", "const float[]", "const double[]":
+	case "const double *", "const float *", "const MKL_Complex16 *", "const MKL_Complex8 *":
 		return "*const Self", true
-	case "double *", "float *", "float[]", "double[]":
+	case "double *", "float *", "MKL_Complex16 *", "MKL_Complex8 *":
 		return "*mut Self", true
-	case "double", "float", "const double", "const float":
+	case "double", "float", "const double", "const float",
+		"MKL_Complex16", "MKL_Complex8", "const MKL_Complex16", "const MKL_Complex8":
 		return "Self", true
-	case "char":
+	case "char", "const char":
 		return "i8", true
-	case "int *":
-		return "*mut i32", true
-	case "const int *":
-		return "*const i32", true
+	case "void *":
+		return "*mut std::ffi::c_void", true
+	case "const void *":
+		return "*const std::ffi::c_void", true
+	}
+
+	// pointers, including the pointer to pointer arrays of the batch routines
+	if pointee, isConst, isPointer := cutPointer(t); isPointer {
+		r, known := getRustParamType(pointee)
+		if isConst {
+			return "*const " + r, known
+		}
+		return "*mut " + r, known
+	}
+
+	// the structs passed by value, such as struct matrix_descr, are imported from the crate by their tags
+	if tag := structTag(t); tag != "" {
+		return tag, false
 	}
 
 	if strings.HasPrefix(t, "const ") {
@@ -329,12 +751,29 @@ func getRustParamType(t string) (string, bool) {
 	return t, false
 }
 
+// rustPointee is the type rust type t points to, which is imported from the crate.
+func rustPointee(t string) string {
+	for {
+		switch {
+		case strings.HasPrefix(t, "*const "):
+			t = strings.TrimPrefix(t, "*const ")
+		case strings.HasPrefix(t, "*mut "):
+			t = strings.TrimPrefix(t, "*mut ")
+		default:
+			return t
+		}
+	}
+}
+
 func (f *funcDef) Params() []string {
 	r := []string{}
 
 	for _, p := range f.args {
-		t, _ := getRustParamType(p.typeName)
-		r = append(r, fmt.Sprintf("%s: %s", p.name, t))
+		t, _ := f.rustParamType(p.typeName)
+		if vsl := f.vslParamType(p); vsl != "" {
+			t = vsl
+		}
+		r = append(r, fmt.Sprintf("%s: %s", rustParamName(p.name), rustFixedArrayType(t, p)))
 	}
 
 	return r
@@ -350,96 +789,292 @@ func (f *funcDef) CallParams() []string {
 	return r
 }
 
-func retrieveTypeSpecifier(r *cc.TypeSpecifier) (typename string) {
-	switch r.Case {
-	case cc.TypeSpecifierEnum:
-		enumspec := r.EnumSpecifier
-		switch enumspec.Case {
-		case cc.EnumSpecifierTag:
-			return enumspec.Token2.SrcStr()
-		default:
-			return enumspec.Token.SrcStr()
+// CallArgs are the parameter names separated by ", ", for languages where parameters and arguments are both just names.
+func (f *funcDef) CallArgs() string {
+	return strings.Join(f.CallParams(), ", ")
+}
+
+// preservedTypedefs are typedefs of arithmetic types that are kept as named,
+// since the element types of the routines are matched by them.
+var preservedTypedefs = map[string]bool{
+	"MKL_F16":   true,
+	"MKL_BF16":  true,
+	"MKL_INT8":  true,
+	"MKL_INT16": true,
+	"MKL_INT32": true,
+}
+
+// typedefName is the name of the typedef t is declared with, such as MKL_INT or CBLAS_LAYOUT.
+// With resolveTypedefs, the typedefs of the arithmetic types, such as MKL_UINT64 or CBLAS_INDEX, collapse to the fundamental types,
+// and the complex types of the other blases to the ones of mkl, except for the ones the element types of the routines are matched by.
+func typedefName(t cc.Type, resolveTypedefs bool) string {
+	name := t.Typedef().Name()
+	if !resolveTypedefs || preservedTypedefs[name] {
+		return name
+	}
+	// size_t is the one typedef of an arithmetic type the backends have a type of their own for, such as usize of rust
+	if name == "size_t" {
+		return name
+	}
+	if complex, isComplex := complexTypedefs[name]; isComplex {
+		return complex
+	}
+	if fundamental, ok := fundamentalType(t); ok {
+		return fundamental
+	}
+
+	return name
+}
+
+// baseTypeName is the type name of t, which is not a pointer other than the ones named by typedefs, with its const.
+// The enums without a typedef are named with their tags, and the structs, such as struct matrix_descr of the sparse routines, with struct.
+func baseTypeName(t cc.Type, resolveTypedefs bool) string {
+	name := ""
+	switch x := t.(type) {
+	case nil:
+		return ""
+	case *cc.EnumType:
+		tag := x.Tag()
+		name = tag.SrcStr()
+		// the c and c++ outputs keep the enum of the enums without a typedef, such as enum DFTI_CONFIG_VALUE.
+		if !resolveTypedefs {
+			name = "enum " + name
 		}
+	case *cc.StructType:
+		tag := x.Tag()
+		name = "struct " + tag.SrcStr()
+	case *cc.UnionType:
+		tag := x.Tag()
+		name = "union " + tag.SrcStr()
 	default:
-		return r.Token.SrcStr()
+		name = arithmeticName(t, resolveTypedefs)
+	}
+	if t.Typedef() != nil {
+		name = typedefName(t, resolveTypedefs)
+	}
+	if t.Attributes().IsConst() {
+		return "const " + name
+	}
+
+	return name
+}
+
+// arithmeticName is the canonical spelling of the arithmetic type t, such as long long of long long int,
+// or with resolveTypedefs, the fundamental type of its size, such as int64_t of long on linux.
+func arithmeticName(t cc.Type, resolveTypedefs bool) string {
+	if fundamental, isFundamental := fundamentalType(t); isFundamental && resolveTypedefs {
+		return fundamental
+	}
+	for name, kind := range arithmeticKinds {
+		if kind == t.Kind() {
+			return name
+		}
+	}
+	switch t.Kind() {
+	case cc.Void:
+		return "void"
+	case cc.Bool:
+		return "_Bool"
 	}
+
+	return t.String()
 }
 
-func retrieveType(r *cc.DeclarationSpecifiers) string {
-	switch r.Case {
-	case cc.DeclarationSpecifiersTypeQual:
-		return r.TypeQualifier.Token.SrcStr() + " " + retrieveType(r.DeclarationSpecifiers)
+// cTypeName is the type name of t, such as const double * or MKL_Complex16 *, with the pointers declared by p.
+// When resolveTypedefs is false, the type is as declared in the header, which is what the generated C and C++ code uses.
+// The pointers named by typedefs, such as VSLStreamStatePtr, and the pointers to functions, are kept as the base of the type.
+// cc drops the qualifiers of the pointers from the types, so the pointers that are pointed to keep their const from p,
+// such as double * const *, a pointer to a const pointer to double, while the const of the type itself is dropped, since it is passed by value.
+func cTypeName(t cc.Type, p *cc.Pointer, resolveTypedefs bool) string {
+	pointers := 0
+	for t.Typedef() == nil && funcPointee(t) == nil {
+		pt, isPointer := t.(*cc.PointerType)
+		if !isPointer {
+			break
+		}
+		t = pt.Elem()
+		pointers++
+	}
+
+	name := ""
+	if fn := funcPointerOf(t, nil); fn != nil {
+		name = fn.cType()
+	} else {
+		name = baseTypeName(t, resolveTypedefs)
+	}
+	if pointers == 0 {
+		return name
+	}
+	consts := pointerConsts(p)
+	suffix := ""
+	for i := 0; i < pointers; i++ {
+		suffix += "*"
+		if i < pointers-1 && i < len(consts) && consts[i] {
+			suffix += " const "
+		}
+	}
 
-	case cc.DeclarationSpecifiersTypeSpec:
-		return retrieveTypeSpecifier(r.TypeSpecifier)
+	return name + " " + suffix
+}
 
-	case cc.DeclarationSpecifiersAlignSpec:
-		fallthrough
-	case cc.DeclarationSpecifiersFunc:
-		fallthrough
-	case cc.DeclarationSpecifiersStorage:
-		fallthrough
-	case cc.DeclarationSpecifiersAttr:
-		fallthrough
-	default:
-		return retrieveType(r.DeclarationSpecifiers)
+// pointerConsts are whether the pointers of p are const, from the one pointing to the type of the declaration,
+// so true and false for double * const *.
+func pointerConsts(p *cc.Pointer) []bool {
+	r := []bool{}
+	for ; p != nil && p.Case != cc.PointerBlock; p = p.Pointer {
+		r = append(r, hasConst(p.TypeQualifiers))
 	}
+
+	return r
+}
+
+// arrayExtent is the size of array parameter a, or empty if it has none or it is not a constant, such as double ab[] or double a[n].
+func arrayExtent(a *cc.ArrayType) string {
+	if a.IsVLA() || a.Len() < 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(a.Len(), 10)
 }
 
-func retrieveParams(r *cc.ParameterList, i int) []funcArg {
-	if r == nil {
+// hasConst is true if the type qualifiers of a pointer contain const.
+func hasConst(q *cc.TypeQualifiers) bool {
+	for ; q != nil; q = q.TypeQualifiers {
+		if q.TypeQualifier != nil && q.TypeQualifier.Case == cc.TypeQualifierConst {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cutPointer is the type pointer type t points to, and if it is const.
+// The pointee of const double * is const double, and the pointee of double * const * is double *, which is const.
+func cutPointer(t string) (pointee string, isConst bool, isPointer bool) {
+	pointee, isPointer = strings.CutSuffix(t, "*")
+	if !isPointer {
+		return t, false, false
+	}
+	pointee = strings.TrimSuffix(pointee, " ")
+	if p, isConstPointer := strings.CutSuffix(pointee, " const"); isConstPointer {
+		return p, true, true
+	}
+
+	return pointee, strings.HasPrefix(pointee, "const ") && !strings.HasSuffix(pointee, "*"), true
+}
+
+// cBaseType is c type t without its pointers, array and const, such as double of const double *.
+func cBaseType(t string) string {
+	t = strings.TrimSuffix(t, "[]")
+	for {
+		pointee, _, isPointer := cutPointer(t)
+		if !isPointer {
+			break
+		}
+		t = pointee
+	}
+
+	return strings.TrimPrefix(t, "const ")
+}
+
+// retrieveParams are the parameters of the function type ft, with the types cc resolved them to,
+// named p0, p1 and so on by their positions when they have no names.
+func retrieveParams(ft *cc.FunctionType) []funcArg {
+	params := ft.Parameters()
+	// (void) has no parameters
+	if len(params) == 1 && params[0].Type().Kind() == cc.Void && params[0].Name() == "" {
 		return nil
 	}
 
-	paramdecl := r.ParameterDeclaration
-	if paramdecl != nil {
-		// typename
-		typeName := retrieveType(paramdecl.DeclarationSpecifiers)
-		paramName := ""
-		switch paramdecl.Case {
-		case cc.ParameterDeclarationAbstract:
-			decl := paramdecl.AbstractDeclarator
-
-			if decl != nil {
-				if decl.Case == cc.AbstractDeclaratorDecl &&
-					decl.DirectAbstractDeclarator.Token.SrcStr() == "[" {
-					typeName = typeName + "[]"
-				}
-				if decl.Case == cc.AbstractDeclaratorPtr {
-					typeName = typeName + " *"
-				}
-			}
-		case cc.ParameterDeclarationDecl:
-			decl := paramdecl.Declarator
-			paramName = decl.DirectDeclarator.Token.SrcStr()
-			if decl.Pointer != nil && decl.Pointer.Case == cc.PointerTypeQual {
-				typeName = typeName + " *"
-			}
-			if decl.DirectDeclarator.Case == cc.DirectDeclaratorArr {
-				typeName = typeName + "[]"
-				paramName = decl.DirectDeclarator.DirectDeclarator.Token.SrcStr()
+	args := make([]funcArg, 0, len(params))
+	for i, param := range params {
+		t := param.Type()
+		var p *cc.Pointer
+		switch {
+		case param.Declarator != nil:
+			p = param.Declarator.Pointer
+		case param.AbstractDeclarator != nil:
+			p = param.AbstractDeclarator.Pointer
+		}
+
+		arg := funcArg{name: param.Name()}
+		if arg.fn = funcPointerOf(t, p); arg.fn != nil {
+			arg.typeName = arg.fn.cType()
+			arg.declType = arg.typeName
+		} else {
+			// the array parameters are pointers, which are declared as the arrays they decay from, such as double a[4]
+			suffix := ""
+			if a, isArray := t.Undecay().(*cc.ArrayType); isArray {
+				t = a.Elem()
+				suffix = "[]"
+				arg.extent = arrayExtent(a)
 			}
+			arg.typeName = cTypeName(t, p, true) + suffix
+			arg.declType = cTypeName(t, p, false) + suffix
 		}
-		if paramName == "" {
-			paramName = fmt.Sprintf("p%d", i)
+		if arg.name == "" {
+			arg.name = fmt.Sprintf("p%d", i)
 		}
-		if typeName == "" {
-			panic(fmt.Sprintf("failed param type: %s", paramdecl.String()))
+		if arg.typeName == "" {
+			failf(exitHeader, "failed to read the type of %s", nodeSource(param.Declarator))
 		}
+		arg.rustName, arg.dontUse = getRustParamType(arg.typeName)
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// decorationWords are the attributes and the asm labels the declarations may carry, such as __attribute__((deprecated)) and __asm__("name"),
+// which nodeSource leaves out with their parentheses, as the parsers of cffi and luajit reading the declarations do not know them.
+var decorationWords = map[string]bool{
+	"__attribute__": true, "__attribute": true, "__declspec": true, "__asm__": true, "__asm": true, "asm": true,
+}
 
-		rustname, dontUse := getRustParamType(typeName)
-		return append([]funcArg{{
-			name:     paramName,
-			typeName: typeName,
-			rustName: rustname,
-			dontUse:  dontUse,
-		}}, retrieveParams(r.ParameterList, i+1)...)
+// nodeSource is the source of n as cc.NodeSource has it, with a space between the words of the tokens
+// the macros paste without separators, such as void bli_dgemm of the typed api of blis, and without the decorations.
+func nodeSource(n cc.Node) string {
+	var b strings.Builder
+	tokens := cc.NodeTokens(n)
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		src := string(t.Src())
+		if decorationWords[src] && i+1 < len(tokens) && tokens[i+1].SrcStr() == "(" {
+			i = closingParen(tokens, i+1)
+			continue
+		}
+		if b.Len() != 0 && (len(t.Sep()) != 0 || isWordByte(b.String()[b.Len()-1]) && src != "" && isWordByte(src[0])) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(src)
 	}
 
-	return retrieveParams(r.ParameterList, i+1)
+	return b.String()
 }
 
-func (flist *funcListInput) retrieveFuncDef(d *cc.ExternalDeclaration) *funcDef {
+// closingParen is the index of the ')' closing the '(' of tokens at i, or the last of tokens if it is not closed.
+func closingParen(tokens []cc.Token, i int) int {
+	for depth := 0; i < len(tokens); i++ {
+		switch tokens[i].SrcStr() {
+		case "(":
+			depth++
+		case ")":
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(tokens) - 1
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// retrieveFuncDefs retrieves the routines of the function list declared by d, each of the declarators of a declaration
+// declaring several, such as void cblas_sscal(...), cblas_dscal(...);.
+func (flist *funcListInput) retrieveFuncDefs(d *cc.ExternalDeclaration) []funcDef {
 	if d == nil {
 		return nil
 	}
@@ -453,113 +1088,595 @@ func (flist *funcListInput) retrieveFuncDef(d *cc.ExternalDeclaration) *funcDef
 		return nil
 	}
 
-	if d.Declaration.InitDeclaratorList == nil {
-		return nil
+	funcs := []funcDef{}
+	for l := d.Declaration.InitDeclaratorList; l != nil; l = l.InitDeclaratorList {
+		if f := flist.retrieveFuncDef(d, l); f != nil {
+			funcs = append(funcs, *f)
+		}
 	}
 
-	if d.Declaration.InitDeclaratorList.InitDeclarator == nil {
+	return funcs
+}
+
+// retrieveFuncDef retrieves the routine of the function list declared by the declarator of l of the declaration d, if it is one.
+func (flist *funcListInput) retrieveFuncDef(d *cc.ExternalDeclaration, l *cc.InitDeclaratorList) *funcDef {
+	if l.InitDeclarator == nil {
 		return nil
 	}
 
 	//	InitDeclarator:
 	//	        Declarator Asm                  // Case InitDeclaratorDecl
 	//	|       Declarator Asm '=' Initializer  // Case InitDeclaratorInit
-	if d.Declaration.InitDeclaratorList.InitDeclarator.Case != cc.InitDeclaratorDecl {
+	if l.InitDeclarator.Case != cc.InitDeclaratorDecl {
 		return nil
 	}
 
-	decl := d.Declaration.InitDeclaratorList.InitDeclarator.Declarator.DirectDeclarator
-
-	if decl == nil {
-		return nil
-	}
+	decl := l.InitDeclarator.Declarator
 
-	// function name
-	if decl.DirectDeclarator == nil || decl.DirectDeclarator.Case != cc.DirectDeclaratorIdent {
+	// the routines are the declarators of the function types, other than the typedefs of them
+	ft, isFunc := decl.Type().(*cc.FunctionType)
+	if !isFunc || decl.IsTypename() {
 		return nil
 	}
 
-	name := decl.DirectDeclarator.Token.SrcStr()
+	name := decl.Name()
 
-	is32, is64, betterName := flist.findFunc(name)
+	fn, found := flist.findFunc(name)
 
-	if !is32 && !is64 {
+	if !found {
 		return nil
 	}
 
-	returnType := retrieveType(d.Declaration.DeclarationSpecifiers)
+	// the pointer of a pointer return type, such as the void * of mkl_malloc, is in the declarator
+	returnType := cTypeName(ft.Result(), decl.Pointer, true)
+	declReturnType := cTypeName(ft.Result(), decl.Pointer, false)
+
+	// the declaration of one of several declarators is its own, with the specifiers they share
+	declaration, line := nodeSource(d.Declaration), d.Position().Line
+	if l != d.Declaration.InitDeclaratorList || l.InitDeclaratorList != nil {
+		declaration = nodeSource(d.Declaration.DeclarationSpecifiers) + " " + nodeSource(l.InitDeclarator) + ";"
+		line = l.InitDeclarator.Position().Line
+	}
 
 	// retrieve arguments
 	fdef := funcDef{
 		RawName:    name,
 		ReturnType: returnType,
-		BetterName: betterName,
-		args:       retrieveParams(decl.ParameterTypeList.ParameterList, 0),
-		is32:       is32,
+		BetterName: fn.betterName,
+		args:       retrieveParams(ft),
+		elem:       fn.elem,
+		out:        fn.out,
+		group:      fn.group,
+		renamed:    fn.renamed,
+		unsafeOnly: fn.unsafeOnly,
+
+		Declaration: declaration,
+		header:      d.Position().Filename,
+		line:        line,
+		deprecated:  isDeprecated(d.Declaration, l),
+		variadic:    ft.IsVariadic(),
+
+		declReturnType: declReturnType,
+	}
+	renameParams(name, fdef.args)
+	for _, arg := range fdef.args {
+		// such as the enums and the typedefs of the header, which the rust output takes as they are named there
+		if !arg.dontUse {
+			debugf("%s takes %s as %s, which is not a built-in rust type", name, arg.name, arg.rustName)
+		}
 	}
 
 	return &fdef
 }
 
+// run writes the output and its side files, such as the bridging header of swift, or the ones of all the targets of the config.
 func run(cmd *cobra.Command, args []string) {
-	if mklPath == "" {
-		mklRoot := os.Getenv("MKLROOT")
-		if mklRoot == "" {
-			mklRoot = "/opt/intel/oneapi/mkl/latest"
+	files := generateAll(cmd)
+	checkOverwrite(files)
+	for _, f := range files {
+		switch {
+		case f.path == "-":
+			_, err := os.Stdout.Write(f.content)
+			orFail(exitOutput, err)
+		// the file another target of the config writes now is kept
+		case f.pruned && slices.ContainsFunc(files, func(other generatedFile) bool { return other.path == f.path && !other.pruned }):
+		case f.pruned && f.content == nil:
+			orFail(exitOutput, os.Remove(f.path))
+			infof("removed %s", f.path)
+		default:
+			orFail(exitOutput, os.WriteFile(f.path, f.content, 0o666))
+			infof("wrote %s", f.path)
 		}
-		mklPath = path.Join(mklRoot, "include", "mkl.h")
 	}
 
-	includePath := path.Dir(mklPath)
+	if depfilePath != "" {
+		writeDepfile(depfilePath, files)
+		infof("wrote %s", depfilePath)
+	}
+}
+
+// generatedFile is a file of the output and its content.
+type generatedFile struct {
+	path    string
+	content []byte
+	// pruned is true for the file of the previous run that is no longer generated with --prune,
+	// which is removed if content is nil, or has the region between its markers emptied.
+	pruned bool
+}
+
+// generate is the output and its side files, which are generated in memory.
+func generate() []generatedFile {
+	if outputFile == "" {
+		failf(exitInput, "--output is required")
+	}
+
+	checkAccelerateOutput()
+	checkProviderOutput()
+	flist, tmplInput := loadRoutines()
+	plainComplex(tmplInput.funcDefs)
+	warnDropped(tmplInput.funcDefs)
 
-	compiler := getOrPanic(cc.NewConfig("", ""))
-	compiler.IncludePaths = append(compiler.IncludePaths, includePath)
+	var b bytes.Buffer
+	files := []generatedFile{}
+	switch {
+	case forC:
+		tmplInput.splitGroups(flist.groups)
+		ccTmpl := getOrPanic(template.New("cc-tmpl").Parse(ccTmplText))
+		orFail(exitTemplate, ccTmpl.Execute(&b, tmplInput))
+	case forC11:
+		c11Tmpl := getOrPanic(template.New("c11-tmpl").Parse(c11TmplText))
+		orFail(exitTemplate, c11Tmpl.Execute(&b, tmplInput))
+	case forJulia:
+		juliaTmpl := getOrPanic(template.New("julia-tmpl").Parse(juliaTmplText))
+		orFail(exitTemplate, juliaTmpl.Execute(&b, tmplInput))
+	case forPython:
+		pyTmpl := getOrPanic(template.New("py-tmpl").Parse(pyTmplText))
+		orFail(exitTemplate, pyTmpl.Execute(&b, tmplInput))
+	case forCffi:
+		cffiTmpl := getOrPanic(template.New("cffi-tmpl").Parse(cffiTmplText))
+		orFail(exitTemplate, cffiTmpl.Execute(&b, tmplInput))
+	case forJava:
+		javaTmpl := getOrPanic(template.New("java-tmpl").Parse(javaTmplText))
+		orFail(exitTemplate, javaTmpl.Execute(&b, tmplInput))
+	case forZig:
+		zigTmpl := getOrPanic(template.New("zig-tmpl").Parse(zigTmplText))
+		orFail(exitTemplate, zigTmpl.Execute(&b, tmplInput))
+	case forFortran:
+		fortranTmpl := getOrPanic(template.New("fortran-tmpl").Parse(fortranTmplText))
+		orFail(exitTemplate, fortranTmpl.Execute(&b, tmplInput))
+	case forSwift:
+		swiftTmpl := getOrPanic(template.New("swift-tmpl").Parse(swiftTmplText))
+		orFail(exitTemplate, swiftTmpl.Execute(&b, tmplInput))
+		var hb bytes.Buffer
+		orFail(exitTemplate, swiftTmpl.ExecuteTemplate(&hb, "bridging-header", tmplInput))
+		files = append(files, generatedFile{path: getSwiftBridgingHeaderPath(outputFile), content: hb.Bytes()})
+	case forHaskell:
+		tmplInput.funcDefs = haskellFuncDefs(tmplInput.funcDefs)
+		haskellTmpl := getOrPanic(template.New("haskell-tmpl").Parse(haskellTmplText))
+		orFail(exitTemplate, haskellTmpl.Execute(&b, tmplInput))
+	case forOCaml:
+		ocamlTmpl := getOrPanic(template.New("ocaml-tmpl").Parse(ocamlTmplText))
+		orFail(exitTemplate, ocamlTmpl.Execute(&b, tmplInput))
+	case forKotlin:
+		kotlinTmpl := getOrPanic(template.New("kotlin-tmpl").Parse(kotlinTmplText))
+		orFail(exitTemplate, kotlinTmpl.Execute(&b, tmplInput))
+		var db bytes.Buffer
+		orFail(exitTemplate, kotlinTmpl.ExecuteTemplate(&db, "cinterop-def", tmplInput))
+		files = append(files, generatedFile{path: getKotlinDefPath(outputFile), content: db.Bytes()})
+	case forLua:
+		luaTmpl := getOrPanic(template.New("lua-tmpl").Parse(luaTmplText))
+		orFail(exitTemplate, luaTmpl.Execute(&b, tmplInput))
+	case forNode:
+		nodeTmpl := getOrPanic(template.New("node-tmpl").Parse(nodeTmplText))
+		orFail(exitTemplate, nodeTmpl.Execute(&b, tmplInput))
+		var db bytes.Buffer
+		orFail(exitTemplate, nodeTmpl.ExecuteTemplate(&db, "dts", tmplInput))
+		files = append(files, generatedFile{path: getNodeDtsPath(outputFile), content: db.Bytes()})
+	case forPascal:
+		pascalTmpl := getOrPanic(template.New("pascal-tmpl").Parse(pascalTmplText))
+		orFail(exitTemplate, pascalTmpl.Execute(&b, tmplInput))
+	case forCrystal:
+		crystalTmpl := getOrPanic(template.New("crystal-tmpl").Parse(crystalTmplText))
+		orFail(exitTemplate, crystalTmpl.Execute(&b, tmplInput))
+	case forAda:
+		adaTmpl := getOrPanic(template.New("ada-tmpl").Parse(adaTmplText))
+		orFail(exitTemplate, adaTmpl.Execute(&b, tmplInput))
+	case forR:
+		rTmpl := getOrPanic(template.New("r-tmpl").Parse(rTmplText))
+		orFail(exitTemplate, rTmpl.Execute(&b, tmplInput))
+		var sb bytes.Buffer
+		orFail(exitTemplate, rTmpl.ExecuteTemplate(&sb, "shim", tmplInput))
+		files = append(files, generatedFile{path: getRShimPath(outputFile), content: sb.Bytes()})
+	case forGo:
+		checkReturns(tmplInput.funcDefs, "go", (*funcDef).goReturnType)
+		checkPrecisions(tmplInput.funcDefs, "go")
+		tmplInput.splitGroups(flist.groups)
+		goTmpl := getOrPanic(template.New("go-tmpl").Parse(goTmplText))
+		orFail(exitTemplate, goTmpl.Execute(&b, tmplInput))
+		newb := formatGo(b.Bytes())
+		b.Reset()
+		getOrPanic(b.Write(newb))
+		// the routines of each group are in a file of their own in the same package
+		for _, g := range tmplInput.Groups() {
+			var gb bytes.Buffer
+			orFail(exitTemplate, goTmpl.ExecuteTemplate(&gb, "group-file", g))
+			files = append(files, generatedFile{
+				path:    getGoGroupPath(outputFile, g.Group()),
+				content: formatGo(gb.Bytes()),
+			})
+		}
+	default:
+		tmplInput.splitGroups(flist.groups)
+		tmplInput.splitUnsafeOnly()
+		checkReturns(tmplInput.funcDefs, "rust", (*funcDef).rustReturnType)
+		checkPrecisions(tmplInput.funcDefs, "rust")
+		rsTmpl := getOrPanic(template.New("rs-tmpl").Parse(rsTmplText))
+		orFail(exitTemplate, rsTmpl.Execute(&b, tmplInput))
+	}
 
-	ccast := getOrPanic(cc.Translate(compiler, []cc.Source{
-		{Name: "<predefined>", Value: compiler.Predefined},
+	// the side files are named after the output, which has no name on stdout
+	if outputFile == "-" && len(files) > 0 {
+		failf(exitInput, "-o - leaves no name for the side files of the output, such as %s", files[0].path)
+	}
+
+	if prune && manifestPath == "" {
+		failf(exitInput, "--prune removes what the previous run recorded in --manifest, which is not set")
+	}
+	if manifestPath != "" {
+		paths := []string{outputFile}
+		for _, f := range files {
+			paths = append(paths, f.path)
+		}
+		m := newManifest(tmplInput, paths)
+		if prune {
+			files = append(files, pruneStale(readManifest(manifestPath), m)...)
+		}
+		files = append(files, generatedFile{path: manifestPath, content: marshalManifest(m)})
+	}
+
+	content := b.Bytes()
+	if inPlace {
+		if outputFile == "-" {
+			failf(exitInput, "--in-place replaces the region of the output on disk, which -o - does not name")
+		}
+		content = spliceRegion(outputFile, content)
+	}
+
+	return append([]generatedFile{{path: outputFile, content: content}}, files...)
+}
+
+// loadRoutines reads the header and the function list, and retrieves the routines the list selects from the header,
+// with the constants, the enums and the structs they take, as the input of the templates.
+// They are read from the cache of --header-cache without translating the header, when the headers are the same as when they were cached.
+func loadRoutines() (*funcListInput, *tmplInput) {
+	if !hasFuncList() {
+		failf(exitInput, "either --input, --preset or the routines of --config is required")
+	}
+
+	content := readFuncLists()
+	readListFiles()
+	names := prefilterNames(content)
+	compiler, sources, headers, key := headerSources(names)
+	cacheFile := routinesCacheFile(key, content, compiler)
+	if flist, input, cached := loadCachedRoutines(cacheFile); cached {
+		return flist, input
+	}
+
+	ccast := parseSources(compiler, sources, headers, names, key)
+	flist, funcs := selectRoutines(ccast, content)
+	input := newTmplInput(ccast, flist, funcs)
+	storeCachedRoutines(cacheFile, flist, input)
+
+	return flist, input
+}
+
+// hasFuncList is true when the function list is given by --input, --preset or the routines of --config.
+func hasFuncList() bool {
+	return len(inputFuncsPaths) > 0 || configRoutines != nil || len(presetNames) > 0
+}
+
+// readFuncLists is the function lists of --input, the routines of --config and the presets of --preset merged together.
+func readFuncLists() string {
+	contents := []string{}
+	for _, input := range inputFuncsPaths {
+		contents = append(contents, readInput(input))
+	}
+	contents = append(contents, configRoutines...)
+	contents = append(contents, presetRoutines()...)
+
+	return strings.Join(contents, "\n")
+}
+
+// translateHeader translates the mkl header, and reads the type map, the wildcards and the parameter names the function list is read with.
+// With --prefilter, only the declarations of the routines of function list content are parsed, or all of them if it is empty.
+func translateHeader(content string) *cc.AST {
+	readListFiles()
+	// the entries of the function list are expanded with the wildcards for the routines the prefilter keeps
+	names := prefilterNames(content)
+	compiler, sources, headers, key := headerSources(names)
+
+	return parseSources(compiler, sources, headers, names, key)
+}
+
+// readListFiles reads the type map, the wildcards and the parameter names the function list is read with.
+func readListFiles() {
+	// the targets of the config may set these differently, so the ones of the previous targets are reset
+	wildcards = slices.Clone(defaultWildcards)
+	if wildcardsPath != "" {
+		wildcards = readWildcards(wildcardsPath)
+	} else {
+		if provider := selectedProvider(); provider != nil && provider.upperComplex {
+			upperComplexWildcard()
+		} else if provider != nil && provider.letters != nil {
+			provider.replaceLetters()
+		}
+		renameWildcards()
+	}
+
+	userTypes = typeMap{}
+	if typeMapPath != "" {
+		userTypes = readTypeMap(typeMapPath)
+	}
+	// the routines of the parameter names may be entries with wildcards
+	userParamNames = map[string]map[string]string{}
+	if paramNamesPath != "" {
+		userParamNames = readParamNames(paramNamesPath)
+	}
+}
+
+// parsedHeaders are the headers already parsed by the header and the macros they are parsed with,
+// so the targets of the config parse the header once for all those reading it the same.
+var parsedHeaders = make(map[string]*cc.AST)
+
+// headerSources are the config of the parser, the sources and the headers the mkl header is parsed with,
+// from the include directories and the macros of the flags, and the key of the header parsed for the routines names,
+// or for all of them if names is nil.
+func headerSources(names []string) (*cc.Config, []cc.Source, []string, string) {
+	provider := selectedProvider()
+	switch {
+	case mklPath == "" && accelerate:
+		defaultAccelerateHeaders()
+	case mklPath == "" && provider != nil:
+		mklPath = filepath.Join(provider.includeDir(), provider.include)
+	}
+	if mklPath == "" {
+		mklPath = defaultMKLHeader()
+	}
+	// the sub-headers of --headers are read in place of mkl.h, from its directory, such as mkl_cblas.h alone for the blas routines
+	if len(subHeaders) > 0 {
+		paths := []string{}
+		for _, h := range subHeaders {
+			if !filepath.IsAbs(h) {
+				h = filepath.Join(filepath.Dir(mklPath), h)
+			}
+			paths = append(paths, h)
+		}
+		mklPath = paths[0]
+		extraHeaders = append(paths[1:], extraHeaders...)
+	}
+
+	goos, goarch := parserTarget()
+	if _, err := cc.NewABI(goos, goarch); err != nil {
+		failf(exitInput, "cannot read the header for %s/%s: %v", goos, goarch, err)
+	}
+	compiler, err := cc.NewConfig(goos, goarch, parserOptions()...)
+	orFail(exitHeader, err)
+	sources := []cc.Source{
+		{Name: "<predefined>"},
 		{Name: "<builtin>", Value: cc.Builtin},
-		{Name: mklPath},
-	}))
+	}
+	headers := append([]string{mklPath}, extraHeaders...)
+	for _, h := range headers {
+		if !slices.Contains(compiler.IncludePaths, filepath.Dir(h)) {
+			compiler.IncludePaths = append(compiler.IncludePaths, filepath.Dir(h))
+		}
+		sources = append(sources, cc.Source{Name: h})
+	}
+	compiler.IncludePaths = append(compiler.IncludePaths, headerIncludeDirs...)
+	// the headers of the toolkit include each other with <>, such as <hip/hip_runtime_api.h>, which are searched in the system include paths
+	if provider != nil {
+		compiler.IncludePaths = append(compiler.IncludePaths, provider.includeDir())
+		compiler.SysIncludePaths = append([]string{provider.includeDir()}, compiler.SysIncludePaths...)
+	}
+	// the directories of --header-include-dir come before the system ones for <> too, as -I does
+	compiler.SysIncludePaths = append(slices.Clone(headerIncludeDirs), compiler.SysIncludePaths...)
+	compiler.EvalAllMacros = true
+	compiler.FS = headerFS{}
+	targetABI = compiler.ABI
+	if ilp64 {
+		for _, m := range ilp64Macros {
+			compiler.Predefined += fmt.Sprintf("\n#define %s 1\n", m)
+		}
+	}
+	compiler.Predefined += callingConventionsPredefined()
+	if accelerate {
+		compiler.Predefined += acceleratePredefined()
+	}
+	if provider != nil {
+		for _, m := range provider.macros {
+			compiler.Predefined += fmt.Sprintf("\n#define %s 1\n", m)
+		}
+	}
+	// NAME of --header-define is defined as 1, as -D of the c compilers does
+	for _, d := range headerDefines {
+		name, value, hasValue := strings.Cut(d, "=")
+		if strings.TrimSpace(name) == "" {
+			failf(exitInput, "--header-define %s has no name, it should be NAME or NAME=VALUE", d)
+		}
+		if !hasValue {
+			value = "1"
+		}
+		compiler.Predefined += fmt.Sprintf("\n#define %s %s\n", name, value)
+	}
+
+	sources[0].Value = compiler.Predefined
+	key := strings.Join(append(append([]string{goos + "/" + goarch, compiler.Predefined}, compiler.IncludePaths...), headers...), "\n")
+	// the header parsed for the routines of a function list has none of the other routines
+	if names != nil {
+		key += "\n" + strings.Join(names, " ")
+	}
+
+	return compiler, sources, headers, key
+}
 
-	flist := readFuncList(inputFuncsPath)
+// parseSources parses the mkl header of sources and headers with compiler,
+// with only the declarations of the routines names and not of the other routines, unless names is nil.
+// The headers parsed by key are parsed once.
+func parseSources(compiler *cc.Config, sources []cc.Source, headers []string, names []string, key string) *cc.AST {
+	if ccast, parsed := parsedHeaders[key]; parsed {
+		return ccast
+	}
+
+	infof("reading %s", strings.Join(headers, ", "))
+	start := time.Now()
+	var ccast *cc.AST
+	var err error
+	if len(headers) > 1 && headerJobs > 1 {
+		if ccast, err = translateHeaders(compiler, sources[:2], headers, names, key); err != nil {
+			debugf("failed to read the headers apart, reading them together: %v", err)
+			ccast = nil
+		}
+	}
+	if ccast == nil {
+		ccast, err = translateSources(compiler, sources, names, key)
+	}
+	if err != nil {
+		failf(exitHeader, "failed to read the header %s, which may need --header-include-dir or --header-define: %v", strings.Join(headers, ", "), err)
+	}
+	infof("read %s in %v", strings.Join(headers, ", "), time.Since(start).Round(time.Millisecond))
+	parsedHeaders[key] = ccast
+
+	return ccast
+}
+
+// selectRoutines retrieves the routines function list content selects from the header.
+func selectRoutines(ccast *cc.AST, content string) (*funcListInput, []funcDef) {
+	flist := readFuncList(content)
+	flist.resolvePlainMacros(ccast)
+	flist.addStreamRoutines(ccast)
+	flist.addDescriptorRoutines(ccast)
 
 	funcs := make([]funcDef, 0)
 
 	cctu := ccast.TranslationUnit
 
-	for thistu := cctu; thistu != nil; thistu = thistu.TranslationUnit {
-		f := flist.retrieveFuncDef(thistu.ExternalDeclaration)
-		if f != nil {
-			funcs = append(funcs, *f)
+	// the walk stops at the declaration of the last of the routines of the function list, once none is left to find,
+	// which never happens when an entry selects a routine the header does not declare or a constant.
+	declared := make(map[string]struct{})
+	remaining := len(flist.names)
+	for thistu := cctu; thistu != nil && remaining > 0; thistu = thistu.TranslationUnit {
+		for _, f := range flist.retrieveFuncDefs(thistu.ExternalDeclaration) {
+			debugf("selected %s as %s by %s", f.RawName, f.BetterName, flist.lineOf(f.RawName))
+			if _, found := declared[f.RawName]; !found {
+				remaining--
+			}
+			declared[f.RawName] = struct{}{}
+			funcs = append(funcs, f)
+		}
+		if remaining == 0 && thistu.TranslationUnit != nil {
+			debugf("found all the %d routines of the function list, skipping the rest of the header", len(declared))
 		}
 	}
-	var b bytes.Buffer
+	flist.logUndeclared(declared)
+	flist.unmatched = flist.unmatchedEntries(ccast, declared)
+	checkUnmatched(flist.unmatched)
+	flist.dropRoutineConstants(funcs)
+	funcs = filterDeprecated(funcs)
+	funcs = plainUnpaired(funcs)
+	flist.checkDuplicates(funcs)
+	infof("selected %d routines", len(funcs))
+
+	return flist, funcs
+}
+
+// pairedElems are the precisions the rust traits and the outputs dispatching between the precisions, such as go and python, pair up.
+var pairedElems = map[elemType]elemType{
+	float32Elem:    float64Elem,
+	float64Elem:    float32Elem,
+	complex64Elem:  complex128Elem,
+	complex128Elem: complex64Elem,
+}
 
+// plainUnpaired is funcs with the routines of a precision missing the routine of the other precision of the same name,
+// such as LAPACKE_dpotrf of LAPACKE_*potrf only:f64 or of a header without LAPACKE_spotrf, as the plain functions of their own names,
+// as if they were selected by them, or of the names of their entries if they are renamed.
+func plainUnpaired(funcs []funcDef) []funcDef {
+	type routine struct {
+		group string
+		name  string
+		elem  elemType
+	}
+
+	selected := make(map[routine]bool)
+	for _, f := range funcs {
+		if f.elem == f.out {
+			selected[routine{f.group, f.BetterName, f.elem}] = true
+		}
+	}
+	for i, f := range funcs {
+		other, paired := pairedElems[f.elem]
+		if !paired || f.elem != f.out || selected[routine{f.group, f.BetterName, other}] {
+			continue
+		}
+		infof("generating %s as a plain function, as %s selects no %s routine to pair it with", f.RawName, f.BetterName, elemName(other))
+		funcs[i].elem, funcs[i].out = noElem, noElem
+		if !f.renamed {
+			funcs[i].BetterName = f.RawName
+		}
+	}
+
+	return funcs
+}
+
+// headerIncludes are the headers the c, c++, go, r and swift outputs include, the ones of --include,
+// or without them, the headers read by their names when they are not mkl.h, such as cblas.h and lapacke.h of openblas.
+// The outputs include mkl.h when it is empty. With --accelerate or a blas other than mkl, they include its header instead of the headers read,
+// such as Accelerate/Accelerate.h or rocblas/rocblas.h.
+func headerIncludes() []string {
+	if len(includes) == 0 && accelerate {
+		return accelerateIncludes
+	}
+	if provider := selectedProvider(); len(includes) == 0 && provider != nil {
+		return []string{provider.include}
+	}
+	if len(includes) > 0 || (filepath.Base(mklPath) == "mkl.h" && len(extraHeaders) == 0) {
+		return includes
+	}
+
+	r := []string{}
+	for _, h := range append([]string{mklPath}, extraHeaders...) {
+		r = append(r, filepath.Base(h))
+	}
+
+	return r
+}
+
+// newTmplInput is the input of the templates for the routines funcs of the function list flist.
+func newTmplInput(ccast *cc.AST, flist *funcListInput, funcs []funcDef) *tmplInput {
 	tmplInput := &tmplInput{
 		funcDefs:        funcs,
 		providerCrate:   mklProviderCrate,
 		DesiredFuncList: flist.desiredFuncList,
-		Includes:        includes,
+		Includes:        headerIncludes(),
+		VSLConstants:    retrieveListedConstants(ccast, flist.constNames, retrieveVSLConstants(ccast, funcs)),
+		Enums:           retrieveEnums(ccast, funcs),
+		typedefs:        neededTypedefs(ccast, funcs),
+		goCblasEnums:    len(ccast.Scope.Nodes["CblasRowMajor"]) > 0,
+		headerVersion:   headerVersion(ccast),
 	}
-	switch {
-	case forC:
-		ccTmpl := getOrPanic(template.New("cc-tmpl").Parse(ccTmplText))
-		orPanic(ccTmpl.Execute(&b, tmplInput))
-	case forGo:
-		goTmpl := getOrPanic(template.New("go-tmpl").Parse(goTmplText))
-		orPanic(goTmpl.Execute(&b, tmplInput))
-		newb := getOrPanic(format.Source(b.Bytes(), format.Options{LangVersion: "1.22"}))
-		b.Reset()
-		getOrPanic(b.Write(newb))
-	default:
-		rsTmpl := getOrPanic(template.New("rs-tmpl").Parse(rsTmplText))
-		orPanic(rsTmpl.Execute(&b, tmplInput))
+	tmplInput.banner = newBanner(tmplInput.headerVersion, flist.desiredFuncList)
+	recordVSLTypes(tmplInput.VSLConstants)
+	// the fields of the structs may be enums, so they are retrieved after the enums
+	tmplInput.Structs = retrieveStructs(ccast, funcs)
+	// the enums and the structs are the returns with their types
+	if reportPath != "" {
+		writeReport(reportPath, flist.unmatched, funcs)
 	}
 
-	orPanic(os.WriteFile(outputFile, b.Bytes(), 0o666))
+	return tmplInput
 }
 
-var longDescription = `generate select mkl bindings for rust, c++, or go.
+var longDescription = `generate select mkl bindings for rust, c++, go, julia, python, java, zig, fortran, swift, haskell, ocaml, kotlin, lua, node.js, c, pascal, crystal, ada, or r.
 
 Use - for stdin, for example
 
@@ -571,7 +1688,9 @@ Use - for stdin, for example
   cblas_*swap
   LAPACKE_*potrs
   LAPACKE_*trtrs
+  LAPACKE_%potrf
   v*RngGaussian
+  VML_HA
   EOF
 `
 
@@ -583,38 +1702,211 @@ Add the generated bindings to the root mod of the crate to use default option "c
 
 var includes []string
 
+var (
+	headerIncludeDirs []string
+	headerDefines     []string
+	// extraHeaders are read after mkl.h, such as lapacke.h after cblas.h of openblas, which has no header including both.
+	extraHeaders []string
+	// subHeaders are the headers next to mkl.h read instead of it, such as mkl_cblas.h and mkl_lapacke.h, which are faster to read than all of mkl.h.
+	subHeaders []string
+)
+
+// ilp64Macros select the 64-bit integers when reading the header with --ilp64, MKL_ILP64 for mkl,
+// OPENBLAS_USE64BITINT and LAPACK_ILP64 for cblas.h and lapacke.h of openblas,
+// and LAPACK_ILP64 and WeirdNEC, which CBLAS_INT is int64_t with, for the reference ones of netlib.
+var ilp64Macros = []string{"MKL_ILP64", "OPENBLAS_USE64BITINT", "LAPACK_ILP64", "WeirdNEC"}
+
 func main() {
 	cmd := &cobra.Command{
-		Short: "generate select bindings of MKL for rust, c++, or go",
+		Short: "generate select bindings of MKL for rust, c++, go, julia, python, java, zig, fortran, swift, haskell, ocaml, kotlin, lua, node.js, c, pascal, crystal, ada, or r",
 		Use:   "gen-mkl-wrapper",
 		Args:  cobra.NoArgs,
 		Long:  longDescription,
 	}
 
-	cmd.Flags().StringVarP(&inputFuncsPath, "input", "i", inputFuncsPath,
-		"list of functions to generate. use * for s/d, use # for S/D. use - for stdin.")
-	cmd.MarkFlagFilename("input")
-	cmd.MarkFlagRequired("input")
-
-	cmd.Flags().StringVarP(&outputFile, "output", "o", outputFile, "output file")
-	cmd.MarkFlagFilename("output", "rs", "h", "go")
-	cmd.MarkFlagRequired("output")
-
-	cmd.Flags().StringVarP(&mklPath, "mkl-header", "m", mklPath, "path to mkl.h file")
-	cmd.MarkFlagFilename("mkl-header", ".h")
-
-	cmd.Flags().StringVarP(&mklProviderCrate, "mkl-provider-crate", "c", mklProviderCrate, crateLongDescription)
-	cmd.Flags().StringVarP(&traitName, "trait-name", "t", traitName, "trait name")
-
-	cmd.Flags().BoolVar(&forC, "for-cc", forC, "output c++")
-
-	cmd.Flags().BoolVar(&forGo, "for-go", forGo, "output go")
-	cmd.Flags().StringVar(&goPackageName, "gopkg", goPackageName, "go package name")
-
-	cmd.Flags().StringVar(&cMacroDefines, "c-macro-defines", cMacroDefines, "c macro defines for header")
-
-	cmd.Flags().StringSliceVar(&includes, "include", includes, "headers to put in cc include")
-
+	cmd.PersistentFlags().StringArrayVarP(&inputFuncsPaths, "input", "i", inputFuncsPaths,
+		"list of functions to generate. use * for s/d, use # for S/D, use % for complex c/z, ^ for h (MKL_F16), @ for bf16 (MKL_BF16), & for the mixed precision bf16bf16f32/f16f16f32/s16s16s32/s8u8s32. use - for stdin. repeat to merge several lists.")
+	cmd.MarkPersistentFlagFilename("input")
+	cmd.PersistentFlags().StringArrayVar(&presetNames, "preset", presetNames, "routines of a preset to add to the function list, one of "+presetNameList()+". repeat to add several.")
+
+	cmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "log reading the header and writing the output, and with -vv, each routine selected and the types falling back to the c types")
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", quiet, "drop the warnings, such as for the deprecated routines")
+	cmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
+	cmd.PersistentFlags().StringVar(&configPath, "config", configPath,
+		`json, yaml or toml file, by its extension, of the flags keyed by their names, and the function list under "routines", or `+strings.Join(defaultConfigNames, ", ")+` in the working directory if there is one. the flags on the command line and the GEN_MKL_ environment variables take precedence.`)
+	cmd.MarkPersistentFlagFilename("config", "json", "yaml", "yml", "toml")
+
+	cmd.PersistentFlags().StringVarP(&outputFile, "output", "o", outputFile, "output file, or - for stdout")
+	cmd.MarkPersistentFlagFilename("output", "rs", "h", "go", "jl", "py", "java", "zig", "f90", "swift", "hs", "ml", "kt", "lua", "js", "pas", "cr", "ads", "R")
+
+	cmd.PersistentFlags().StringVarP(&mklPath, "mkl-header", "m", mklPath, "path to mkl.h file, or cblas.h or lapacke.h of another blas, such as openblas")
+	cmd.MarkPersistentFlagFilename("mkl-header", ".h")
+	cmd.PersistentFlags().BoolVar(&accelerate, "accelerate", accelerate,
+		"read cblas_new.h and lapack.h of the accelerate framework of macOS, from the sdk of SDKROOT without --mkl-header, with the new lapack interface, and include Accelerate/Accelerate.h")
+	cmd.PersistentFlags().StringArrayVar(&extraHeaders, "extra-header", extraHeaders,
+		"headers to read after --mkl-header, such as lapacke.h after cblas.h of openblas. repeat to read several.")
+	cmd.MarkPersistentFlagFilename("extra-header", ".h")
+	cmd.PersistentFlags().StringSliceVar(&subHeaders, "headers", subHeaders,
+		"headers in the directory of mkl.h to read instead of it, such as mkl_cblas.h,mkl_lapacke.h, which is faster when the routines are in a few of them. the outputs include these headers.")
+	cmd.MarkPersistentFlagFilename("headers", ".h")
+	cmd.PersistentFlags().BoolVar(&prefilter, "prefilter", prefilter,
+		"parse only the declarations of the routines of the function list, the types and the constants, dropping the other routines after preprocessing the header, which is much faster for the lists of a few routines. --prefilter=false parses all of the header.")
+	cmd.PersistentFlags().BoolVar(&headerCache, "header-cache", headerCache,
+		"cache the declarations the prefilter keeps and the routines selected, so the runs reading the same headers with the same macros for the same routines skip preprocessing them, and translating them with the same flags. --header-cache=false reads the header without the cache.")
+	cmd.PersistentFlags().StringVar(&headerCacheDir, "header-cache-dir", headerCacheDir, "directory of --header-cache. default to gen-mkl-wrapper in the cache directory of the user, such as ~/.cache/gen-mkl-wrapper")
+	cmd.MarkPersistentFlagDirname("header-cache-dir")
+	cmd.PersistentFlags().IntVarP(&headerJobs, "jobs", "j", headerJobs,
+		"number of the headers read at once, each alone, when several are read, such as by --headers or --extra-header. --jobs 1 reads them one after another in one translation unit, for the headers using the macros of the ones before them.")
+	addProviderFlags(cmd)
+	addABIFlags(cmd)
+
+	cmd.PersistentFlags().StringSliceVarP(&headerIncludeDirs, "header-include-dir", "I", headerIncludeDirs,
+		"directories to search for the headers mkl.h includes, besides the directory of mkl.h, including the ones included with <>, as -I of the c compilers. also --include-path. repeat to add several.")
+	cmd.PersistentFlags().StringSliceVarP(&headerDefines, "header-define", "D", headerDefines,
+		"macros to define when reading the header, as NAME or NAME=VALUE, such as MKL_DIRECT_CALL or LAPACK_COMPLEX_STRUCTURE. also --define. repeat to define several.")
+	cmd.PersistentFlags().BoolVar(&ilp64, "ilp64", ilp64, "define MKL_ILP64, or OPENBLAS_USE64BITINT, LAPACK_ILP64 and WeirdNEC for openblas and netlib, when reading the header, so MKL_INT, blasint, CBLAS_INT and lapack_int are 64-bit integers")
+	cmd.PersistentFlags().BoolVar(&ilp64Symbols, "ilp64-symbols", ilp64Symbols,
+		"call the _64 suffixed ILP64 symbols, such as cblas_dgemm_64, keeping the names of the wrappers without the suffix")
+	cmd.PersistentFlags().StringVar(&typeMapPath, "type-map", typeMapPath,
+		`json file of the go, rust and c++ types of the c types, taking precedence over the built-in ones, such as {"go": {"MKL_UINT": "uint32"}}`)
+	cmd.PersistentFlags().StringVar(&paramNamesPath, "param-names", paramNamesPath,
+		`json file of the new names of the parameters by the routines, such as {"vslDeleteStream": {"p0": "stream"}}`)
+	cmd.MarkPersistentFlagFilename("param-names", "json")
+	cmd.PersistentFlags().BoolVar(&allowRawReturns, "allow-raw-returns", allowRawReturns,
+		"keep the c types of the go and rust returns without a mapping instead of failing")
+	cmd.PersistentFlags().StringVar(&wildcardsPath, "wildcards", wildcardsPath,
+		"file replacing the default wildcards, one per line as the wildcard followed by the letters and their types, such as * d=f64 s=f32")
+	cmd.PersistentFlags().StringVar(&lowerWildcard, "wildcard", lowerWildcard,
+		"character in place of d/s, replacing *, or empty to drop it")
+	cmd.PersistentFlags().StringVar(&upperWildcard, "upper-wildcard", upperWildcard,
+		"character in place of D/S, replacing #, or empty to drop it")
+	cmd.PersistentFlags().BoolVar(&fortranSymbols, "fortran-symbols", fortranSymbols,
+		"call the fortran symbols, such as dgemm_ for *gemm, keeping the names of the wrappers without the suffix")
+	cmd.PersistentFlags().StringVar(&fortranSymbolSuffix, "fortran-symbol-suffix", fortranSymbolSuffix,
+		"suffix of the fortran symbols with --fortran-symbols, such as the _ of dgemm_, or empty for dgemm")
+
+	cmd.PersistentFlags().BoolVar(&skipDeprecated, "skip-deprecated", skipDeprecated,
+		"leave out the routines marked deprecated in the header instead of warning about them")
+	cmd.PersistentFlags().BoolVar(&failOnDeprecated, "fail-on-deprecated", failOnDeprecated, "fail if any routine marked deprecated in the header is selected")
+	cmd.PersistentFlags().StringVar(&manifestPath, "manifest", manifestPath,
+		"json file to write the wrappers generated to, with the routines they call, their parameters and where the header declares them")
+	cmd.PersistentFlags().BoolVar(&prune, "prune", prune,
+		"remove the files the previous run recorded in --manifest and this one no longer writes, keeping the hand-written code around the markers of --in-place")
+	cmd.PersistentFlags().StringVar(&depfilePath, "depfile", depfilePath,
+		"file to write the make rule of the outputs on the headers included and the files read, such as the function list, to")
+	cmd.PersistentFlags().BoolVar(&force, "force", force, "overwrite the output and its side files even if they are not generated by gen-mkl-wrapper")
+	cmd.PersistentFlags().BoolVar(&inPlace, "in-place", inPlace,
+		"replace only the lines between the gen-mkl-wrapper:begin and gen-mkl-wrapper:end markers of the output, keeping the code around them")
+	cmd.PersistentFlags().BoolVar(&strict, "strict", strict,
+		"fail if an entry of the function list selects none or only some of its routines, or names neither a routine nor a constant")
+	cmd.PersistentFlags().StringVar(&reportPath, "report", reportPath,
+		"file to write the entries of the function list matching none or only some of their routines, and the returns without rust or go types, to")
+
+	cmd.PersistentFlags().StringVarP(&mklProviderCrate, "mkl-provider-crate", "c", mklProviderCrate, crateLongDescription)
+	cmd.PersistentFlags().StringVarP(&traitName, "trait-name", "t", traitName, "trait name")
+	cmd.PersistentFlags().BoolVar(&importEnums, "import-enums", importEnums,
+		"import the enums the routines take, such as CBLAS_LAYOUT, from the provider crate instead of defining them in the rust output")
+	cmd.PersistentFlags().BoolVar(&rustFixedArrays, "rust-fixed-arrays", rustFixedArrays,
+		"take the array parameters with an extent, such as const float a[4], as pointers to rust arrays, such as *const [Self; 4]")
+	cmd.PersistentFlags().BoolVar(&stridedBatchHelpers, "strided-batch-helpers", stridedBatchHelpers,
+		"add to the rust trait a _packed version of cblas_?gemm_batch_strided, with the strides derived from the dimensions")
+	cmd.PersistentFlags().StringVar(&complexTraitName, "complex-trait-name", complexTraitName, "trait name of the complex routines")
+	cmd.PersistentFlags().StringVar(&rustComplexType, "rust-complex-type", rustComplexType,
+		"generic rust type the complex routines are implemented for, it must have the layout of MKL_Complex8/MKL_Complex16")
+	cmd.PersistentFlags().StringVar(&f16TraitName, "f16-trait-name", f16TraitName, "trait name of the MKL_F16 routines")
+	cmd.PersistentFlags().StringVar(&bf16TraitName, "bf16-trait-name", bf16TraitName, "trait name of the MKL_BF16 routines")
+	cmd.PersistentFlags().StringVar(&mixedF32TraitName, "mixed-f32-trait-name", mixedF32TraitName, "trait name of the mixed precision routines with float output")
+	cmd.PersistentFlags().StringVar(&mixedI32TraitName, "mixed-i32-trait-name", mixedI32TraitName, "trait name of the mixed precision routines with MKL_INT32 output")
+
+	cmd.PersistentFlags().BoolVar(&forC, "for-cc", forC, "output c++")
+
+	cmd.PersistentFlags().BoolVar(&forGo, "for-go", forGo, "output go")
+	cmd.PersistentFlags().StringVar(&goPackageName, "gopkg", goPackageName, "go package name")
+	cmd.PersistentFlags().BoolVar(&goGenerate, "go-generate", goGenerate,
+		"run from //go:generate: go in the package of $GOFILE, written to $GOFILE with _mkl suffix, quiet unless -v, and the failures logged as $GOFILE:$GOLINE: message")
+
+	cmd.PersistentFlags().BoolVar(&forJulia, "for-julia", forJulia, "output julia")
+	cmd.PersistentFlags().StringVar(&juliaModuleName, "julia-module", juliaModuleName, "julia module name")
+	cmd.PersistentFlags().StringVar(&juliaLibrary, "julia-lib", juliaLibrary, "shared library the julia bindings ccall into")
+
+	cmd.PersistentFlags().BoolVar(&forPython, "for-python", forPython, "output python with ctypes")
+	cmd.PersistentFlags().BoolVar(&forCffi, "for-cffi", forCffi, "output python with cffi in ABI mode")
+	cmd.PersistentFlags().StringVar(&pythonLibrary, "python-lib", pythonLibrary, "library name the python bindings load with ctypes or cffi")
+
+	cmd.PersistentFlags().BoolVar(&forJava, "for-java", forJava, "output java with the foreign function and memory api")
+	cmd.PersistentFlags().StringVar(&javaPackageName, "java-package", javaPackageName, "java package name")
+	cmd.PersistentFlags().StringVar(&javaLibrary, "java-lib", javaLibrary, "library name the java bindings look up symbols from")
+
+	cmd.PersistentFlags().BoolVar(&forZig, "for-zig", forZig, "output zig")
+
+	cmd.PersistentFlags().BoolVar(&forFortran, "for-fortran", forFortran, "output fortran module with iso_c_binding")
+	cmd.PersistentFlags().StringVar(&fortranModuleName, "fortran-module", fortranModuleName, "fortran module name")
+
+	cmd.PersistentFlags().BoolVar(&forSwift, "for-swift", forSwift, "output swift and its bridging header")
+	cmd.PersistentFlags().StringVar(&swiftProtocolName, "swift-protocol", swiftProtocolName, "swift protocol name")
+	cmd.PersistentFlags().StringVar(&swiftBridgingHeader, "swift-bridging-header", swiftBridgingHeader,
+		"output path of the swift bridging header, default to the output with -Bridging-Header.h suffix")
+	cmd.MarkPersistentFlagFilename("swift-bridging-header", "h")
+
+	cmd.PersistentFlags().BoolVar(&forHaskell, "for-haskell", forHaskell, "output haskell with the trait as a type class")
+	cmd.PersistentFlags().StringVar(&haskellModuleName, "haskell-module", haskellModuleName, "haskell module name")
+
+	cmd.PersistentFlags().BoolVar(&forOCaml, "for-ocaml", forOCaml, "output ocaml with ctypes")
+	cmd.PersistentFlags().StringVar(&ocamlModuleTypeName, "ocaml-module-type", ocamlModuleTypeName, "ocaml module type name")
+
+	cmd.PersistentFlags().BoolVar(&forKotlin, "for-kotlin", forKotlin, "output kotlin/native and its cinterop def file")
+	cmd.PersistentFlags().StringVar(&kotlinPackageName, "kotlin-package", kotlinPackageName, "kotlin package name")
+	cmd.PersistentFlags().StringVar(&kotlinDefFile, "kotlin-def", kotlinDefFile, "output path of the cinterop def file, default to the output with .def extension")
+	cmd.MarkPersistentFlagFilename("kotlin-def", "def")
+	cmd.PersistentFlags().StringVar(&kotlinLinkerOpts, "kotlin-linker-opts", kotlinLinkerOpts, "linkerOpts in the cinterop def file")
+
+	cmd.PersistentFlags().BoolVar(&forLua, "for-lua", forLua, "output luajit ffi module")
+	cmd.PersistentFlags().StringVar(&luaLibrary, "lua-lib", luaLibrary, "library name the lua module loads with ffi.load")
+
+	cmd.PersistentFlags().BoolVar(&forNode, "for-node", forNode, "output node.js module using koffi and its typescript declarations")
+	cmd.PersistentFlags().StringVar(&nodeLibrary, "node-lib", nodeLibrary, "library the node.js module loads with koffi")
+	cmd.PersistentFlags().StringVar(&nodeDtsFile, "node-dts", nodeDtsFile, "output path of the typescript declarations, default to the output with .d.ts extension")
+	cmd.MarkPersistentFlagFilename("node-dts", "ts")
+
+	cmd.PersistentFlags().BoolVar(&forC11, "for-c", forC11, "output c11 macros dispatching with _Generic")
+
+	cmd.PersistentFlags().BoolVar(&forPascal, "for-pascal", forPascal, "output free pascal/delphi unit")
+	cmd.PersistentFlags().StringVar(&pascalUnitName, "pascal-unit", pascalUnitName, "name of the pascal unit")
+	cmd.PersistentFlags().StringVar(&pascalLibrary, "pascal-lib", pascalLibrary, "library the pascal routines are imported from")
+
+	cmd.PersistentFlags().BoolVar(&forCrystal, "for-crystal", forCrystal, "output crystal lib block and module")
+	cmd.PersistentFlags().StringVar(&crystalModuleName, "crystal-module", crystalModuleName, "name of the crystal module")
+	cmd.PersistentFlags().StringVar(&crystalLibrary, "crystal-lib", crystalLibrary, "library the crystal lib block links")
+
+	cmd.PersistentFlags().BoolVar(&forAda, "for-ada", forAda, "output ada package spec")
+	cmd.PersistentFlags().StringVar(&adaPackageName, "ada-package", adaPackageName, "name of the ada package")
+
+	cmd.PersistentFlags().BoolVar(&forR, "for-r", forR, "output r wrappers and the c shim they call")
+	cmd.PersistentFlags().StringVar(&rPackageName, "r-package", rPackageName, "name of the r package the shim is registered for")
+	cmd.PersistentFlags().StringVar(&rShimFile, "r-shim", rShimFile, "output path of the c shim, default to the output with .c extension")
+	cmd.MarkPersistentFlagFilename("r-shim", "c")
+
+	cmd.PersistentFlags().StringVar(&cMacroDefines, "c-macro-defines", cMacroDefines, "c macro defines for header")
+
+	cmd.PersistentFlags().StringSliceVar(&includes, "include", includes, "headers to put in cc include, default to mkl.h, or --mkl-header and --extra-header by their names for the other headers")
+
+	// the flags are persistent, so the subcommands take them too
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if goGenerate {
+			startGoGenerate()
+		}
+		applyConfig(cmd, args)
+		if goGenerate {
+			applyGoGenerate(cmd)
+		}
+		recordFlags(cmd)
+	}
 	cmd.Run = run
-	cmd.Execute()
+	cmd.SetGlobalNormalizationFunc(normalizeFlagName)
+	addCommands(cmd)
+	// cobra prints the error and the usage of the flags and arguments it fails on
+	if err := cmd.Execute(); err != nil {
+		os.Exit(exitInput)
+	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// manifestPath is the file of --manifest, the json of the wrappers generated and the routines they call.
+var manifestPath = ""
+
+// manifest is the wrappers of the output and the routines they call, for the tools auditing the symbols
+// the bindings link or documenting them.
+type manifest struct {
+	// Banner is the banner of the output, recording how it is generated.
+	Banner []string `json:"banner"`
+	Output string   `json:"output"`
+	// Files are the output and its side files, such as the go files of the groups, which --prune removes once they are no longer written.
+	Files    []string          `json:"files"`
+	Wrappers []manifestWrapper `json:"wrappers"`
+}
+
+// manifestWrapper is a wrapper under the name of the function list, such as cblas_gemm, which the outputs
+// capitalize as their languages require, such as Cblas_gemm for go.
+type manifestWrapper struct {
+	Name     string            `json:"name"`
+	Group    string            `json:"group,omitempty"`
+	Routines []manifestRoutine `json:"routines"`
+}
+
+// manifestRoutine is a routine of the header a wrapper calls, by the symbol it links.
+type manifestRoutine struct {
+	Symbol string `json:"symbol"`
+	// Type is the element type the wrapper calls the routine for, such as f64, which is empty for the plain routines.
+	Type   string    `json:"type,omitempty"`
+	Return string    `json:"return"`
+	Params []irParam `json:"params"`
+	// Header and Line are where the header declares the routine.
+	Header string `json:"header"`
+	Line   int    `json:"line"`
+}
+
+// newManifest is the manifest of the wrappers of i, in the order of the routines, written to files.
+func newManifest(i *tmplInput, files []string) *manifest {
+	m := &manifest{Banner: i.banner, Output: outputFile, Files: files, Wrappers: []manifestWrapper{}}
+	wrappers := make(map[string]int)
+	for _, f := range i.funcDefs {
+		idx, seen := wrappers[f.BetterName]
+		if !seen {
+			idx = len(m.Wrappers)
+			wrappers[f.BetterName] = idx
+			m.Wrappers = append(m.Wrappers, manifestWrapper{Name: f.BetterName, Group: f.group})
+		}
+		m.Wrappers[idx].Routines = append(m.Wrappers[idx].Routines, manifestRoutine{
+			Symbol: f.RawName,
+			Type:   elemName(f.elem),
+			Return: f.ReturnType,
+			Params: irParams(f.args),
+			Header: f.header,
+			Line:   f.line,
+		})
+	}
+
+	return m
+}
+
+// marshalManifest is the json of manifest m.
+func marshalManifest(m *manifest) []byte {
+	b := getOrPanic(json.MarshalIndent(m, "", "  "))
+
+	return append(b, '\n')
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultMKLHeader is mkl.h under the include directory of MKLROOT, or without it, of the cflags of pkg-config for mkl,
+// or the first of mklHeaders found, or the first of them when none is, which the failure of reading the header names.
+func defaultMKLHeader() string {
+	if root := os.Getenv("MKLROOT"); root != "" {
+		return filepath.Join(root, "include", "mkl.h")
+	}
+	if h := pkgConfigHeader(); h != "" {
+		return h
+	}
+
+	headers := mklHeaders()
+	for _, h := range headers {
+		if isFile(h) {
+			debugf("found %s", h)
+			return h
+		}
+	}
+
+	return headers[0]
+}
+
+// isFile is true when path is a file, and not a directory.
+func isFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// mklPkgConfig is the package of mkl for pkg-config the include directory is queried from, of the 64-bit integers with --ilp64,
+// which all of the packages of mkl share.
+func mklPkgConfig() string {
+	if ilp64 {
+		return "mkl-dynamic-ilp64-seq"
+	}
+
+	return "mkl-dynamic-lp64-seq"
+}
+
+// pkgConfigHeader is mkl.h in the include directories of the cflags of mkl, by pkg-config, or $PKG_CONFIG if it is set,
+// or empty if neither pkg-config nor mkl.h is found.
+func pkgConfigHeader() string {
+	pkgConfig := os.Getenv("PKG_CONFIG")
+	if pkgConfig == "" {
+		pkgConfig = "pkg-config"
+	}
+	out, err := exec.Command(pkgConfig, "--cflags", mklPkgConfig()).Output()
+	if err != nil {
+		debugf("no %s by %s: %v", mklPkgConfig(), pkgConfig, err)
+		return ""
+	}
+	for _, flag := range strings.Fields(string(out)) {
+		if dir, isInclude := strings.CutPrefix(flag, "-I"); isInclude && isFile(filepath.Join(dir, "mkl.h")) {
+			debugf("found mkl.h in %s by %s", dir, pkgConfig)
+			return filepath.Join(dir, "mkl.h")
+		}
+	}
+
+	return ""
+}
+
+// mklHeaders are where mkl.h is installed, by the environment of oneAPI, ONEAPI_ROOT or CMPLR_ROOT of its compiler next to mkl,
+// by the environments of conda and python, CONDA_PREFIX and VIRTUAL_ENV, and ~/.local, then by default on the platform the generator runs on, oneAPI under Program Files on windows,
+// oneAPI or homebrew on macOS, and oneAPI, the older parallel studio, pip or the packages of the distributions on linux.
+func mklHeaders() []string {
+	roots := []string{}
+	if root := os.Getenv("ONEAPI_ROOT"); root != "" {
+		roots = append(roots, filepath.Join(root, "mkl", "latest"))
+	}
+	// CMPLR_ROOT is the compiler of a version under the root, such as /opt/intel/oneapi/compiler/2024.2
+	if root := os.Getenv("CMPLR_ROOT"); root != "" {
+		roots = append(roots, filepath.Join(root, "..", "..", "mkl", "latest"))
+	}
+	// mkl-include of conda, or the wheel of pip, installs the headers in the include directory of the environment, under Library on windows,
+	// or of ~/.local with pip install --user
+	for _, env := range []string{"CONDA_PREFIX", "VIRTUAL_ENV"} {
+		switch prefix := os.Getenv(env); {
+		case prefix == "":
+		case runtime.GOOS == "windows":
+			roots = append(roots, filepath.Join(prefix, "Library"), prefix)
+		default:
+			roots = append(roots, prefix)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && runtime.GOOS != "windows" {
+		roots = append(roots, filepath.Join(home, ".local"))
+	}
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := os.Getenv(env); dir != "" {
+				roots = append(roots, filepath.Join(dir, "Intel", "oneAPI", "mkl", "latest"))
+			}
+		}
+		roots = append(roots, `C:\Program Files (x86)\Intel\oneAPI\mkl\latest`)
+	case "darwin":
+		roots = append(roots, "/opt/intel/oneapi/mkl/latest", "/opt/intel/mkl")
+		if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+			roots = append(roots, prefix)
+		}
+		roots = append(roots, "/opt/homebrew", "/usr/local")
+	default:
+		roots = append(roots, "/opt/intel/oneapi/mkl/latest", "/opt/intel/mkl", "/usr/local")
+	}
+
+	headers := []string{}
+	for _, root := range roots {
+		headers = append(headers, filepath.Join(root, "include", "mkl.h"))
+	}
+	// the packages of debian and ubuntu install the headers in their own directory
+	if runtime.GOOS == "linux" {
+		headers = append(headers, "/usr/include/mkl/mkl.h", "/usr/include/mkl.h")
+	}
+
+	return headers
+}
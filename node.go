@@ -0,0 +1,185 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed node.tmpl
+var nodeTmplText string
+
+var (
+	forNode     = false
+	nodeLibrary = "libmkl_rt.so"
+	nodeDtsFile = ""
+)
+
+func (*tmplInput) NodeLibrary() string {
+	return nodeLibrary
+}
+
+// getNodeDtsPath is where the typescript declarations are written. By default it is next to the javascript output.
+func getNodeDtsPath(output string) string {
+	if nodeDtsFile != "" {
+		return nodeDtsFile
+	}
+
+	return strings.TrimSuffix(output, ".js") + ".d.ts"
+}
+
+// getNodeParamType returns the koffi type and typescript type of c type t, and if the type is an array of the float type.
+// self is the c float type, and selfArray is the typescript type used for float arrays.
+// Types that are not known are passed as void pointers.
+func getNodeParamType(t string, self string, selfArray string) (string, string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.nodeType, "number", false
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		// vslNewStream and DftiCreateDescriptor write the handle to the first element of the array
+		return "_Inout_ void **", "unknown[]", false
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return fmt.Sprintf("const %s *", self), selfArray, true
+	case "double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("%s *", self), selfArray, true
+	case "double", "float", "const double", "const float":
+		return self, "number", false
+	case "char", "const char":
+		return "char", "string | number", false
+	case "const char *":
+		return "const char *", "string", false
+	case "int *":
+		return "int *", "Int32Array", false
+	case "const int *":
+		return "const int *", "Int32Array", false
+	case "int64_t *":
+		return "int64_t *", "BigInt64Array", false
+	case "const int64_t *":
+		return "const int64_t *", "BigInt64Array", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "int", "number", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "int", "number", false
+	case isStruct(t):
+		// declared with koffi.struct, and as an interface of the same name in typescript
+		tag := structTag(t)
+		return tag, tag, false
+	}
+
+	return "void *", "unknown", false
+}
+
+// NodeFields are the members of the koffi struct.
+func (s *structDef) NodeFields() string {
+	r := []string{}
+	for _, field := range s.fields {
+		t, _, _ := getNodeParamType(field.typeName, fieldSelf(field, "double", "float"), fieldSelf(field, "Float64Array", "Float32Array"))
+		r = append(r, fmt.Sprintf("%s: %q", field.name, t))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// NodeDtsFields are the members of the typescript interface of the struct.
+func (s *structDef) NodeDtsFields() string {
+	r := []string{}
+	for _, field := range s.fields {
+		_, t, _ := getNodeParamType(field.typeName, fieldSelf(field, "double", "float"), fieldSelf(field, "Float64Array", "Float32Array"))
+		r = append(r, fmt.Sprintf("%s: %s;", field.name, t))
+	}
+
+	return strings.Join(r, " ")
+}
+
+func getNodeReturnType(t string, self string) (string, string) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.nodeType, "number"
+	}
+
+	switch t {
+	case "void":
+		return "void", "void"
+	case "float", "double":
+		return self, "number"
+	}
+	if isEnum(t) {
+		return "int", "number"
+	}
+	if isStruct(t) {
+		tag := structTag(t)
+		return tag, tag
+	}
+
+	return "void *", "unknown"
+}
+
+// NodeSelf is the c type of the float type.
+func (f *funcDef) NodeSelf() string {
+	if f.is32() {
+		return "float"
+	}
+	return "double"
+}
+
+// NodeSelfArray is the typed array of the float type.
+func (f *funcDef) NodeSelfArray() string {
+	if f.is32() {
+		return "Float32Array"
+	}
+	return "Float64Array"
+}
+
+// NodeDeclare is the koffi declaration of the routine.
+func (f *funcDef) NodeDeclare() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _, _ := getNodeParamType(p.typeName, f.NodeSelf(), f.NodeSelfArray())
+		r = append(r, fmt.Sprintf("%q", t))
+	}
+	ret, _ := getNodeReturnType(f.ReturnType, f.NodeSelf())
+
+	return fmt.Sprintf("lib.func(%q, %q, [%s])", f.RawName, ret, strings.Join(r, ", "))
+}
+
+// NodeCallParams are the arguments forwarded to the koffi function, with chars converted to their codes.
+func (f *funcDef) NodeCallParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		if p.typeName == "char" {
+			r = append(r, fmt.Sprintf("toChar(%s)", p.name))
+		} else {
+			r = append(r, p.name)
+		}
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// NodeDts is the typescript signature of the routine.
+func (f *funcDef) NodeDts() string {
+	r := []string{}
+	for _, p := range f.args {
+		_, t, _ := getNodeParamType(p.typeName, f.NodeSelf(), f.NodeSelfArray())
+		r = append(r, fmt.Sprintf("%s: %s", p.name, t))
+	}
+	_, ret := getNodeReturnType(f.ReturnType, f.NodeSelf())
+
+	return fmt.Sprintf("%s(%s): %s", f.BetterName, strings.Join(r, ", "), ret)
+}
+
+// NodeDispatchArg is the parameter whose array type decides which precision is called.
+// When the routine takes no float arrays, the float64 one is always called.
+func (f *GoFuncPair) NodeDispatchArg() string {
+	arg, isArray := f.dispatchArg()
+	if !isArray {
+		return ""
+	}
+
+	return arg.name
+}
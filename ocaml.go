@@ -0,0 +1,195 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed ocaml.tmpl
+var ocamlTmplText string
+
+var (
+	forOCaml            = false
+	ocamlModuleTypeName = "MKL_ROUTINES"
+)
+
+func (*tmplInput) OCamlModuleTypeName() string {
+	return ocamlModuleTypeName
+}
+
+func (f *funcDef) OCamlName() string {
+	return lowerLeading(f.BetterName)
+}
+
+func (c *constDef) OCamlName() string {
+	return lowerLeading(c.Name)
+}
+
+// OCamlSelf is the ctypes type of the float type.
+func (f *funcDef) OCamlSelf() string {
+	if f.is32() {
+		return "float"
+	}
+	return "double"
+}
+
+// getOCamlParamType returns the ctypes type and the ocaml type of c type t, and if the type is an array of the float type.
+// self is the ctypes type of the float type, and float arrays are passed as bigarrays of element elt.
+// Types that are not known are passed as void pointers.
+func getOCamlParamType(t string, self string, elt string) (string, string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.ocamlType, integer.ocamlValue, false
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		return "ptr (ptr void)", "unit ptr ptr", false
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("ptr %s", self), fmt.Sprintf("(float, %s, Bigarray.c_layout) Bigarray.Genarray.t", elt), true
+	case "double", "float", "const double", "const float":
+		return self, "float", false
+	case "char", "const char":
+		return "char", "char", false
+	case "const char *":
+		return "string", "string", false
+	case "int *", "const int *":
+		return "ptr int", "int ptr", false
+	case "int64_t *", "const int64_t *":
+		return "ptr int64_t", "int64 ptr", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "int", "int", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "int", "int", false
+	case isStruct(t):
+		name := structNames[structTag(t)].OCamlName()
+		return name, name + " structure", false
+	}
+
+	return "ptr void", "unit ptr", false
+}
+
+// OCamlName is the name of the structure and of its type, which start with a lower case letter, such as mkl_Complex16.
+func (s *structDef) OCamlName() string {
+	return lowerLeading(s.Name)
+}
+
+// OCamlFields are the ctypes fields of the structure, each bound to the name of the struct followed by the name of the field.
+func (s *structDef) OCamlFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		t, _, _ := getOCamlParamType(field.typeName, fieldSelf(field, "double", "float"), "")
+		r = append(r, fmt.Sprintf("let %s_%s = field %s \"%s\" %s", s.OCamlName(), field.name, s.OCamlName(), field.name, t))
+	}
+
+	return r
+}
+
+func getOCamlReturnType(t string, self string) (string, string) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.ocamlType, integer.ocamlValue
+	}
+
+	switch t {
+	case "void":
+		return "void", "unit"
+	case "float", "double":
+		return self, "float"
+	}
+	if isEnum(t) {
+		return "int", "int"
+	}
+	if isStruct(t) {
+		name := structNames[structTag(t)].OCamlName()
+		return name, name + " structure"
+	}
+
+	return "ptr void", "unit ptr"
+}
+
+// OCamlForeign is the ctypes function type of the routine.
+func (f *funcDef) OCamlForeign() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _, _ := getOCamlParamType(p.typeName, f.OCamlSelf(), "")
+		r = append(r, t)
+	}
+	if len(r) == 0 {
+		r = append(r, "void")
+	}
+	ret, _ := getOCamlReturnType(f.ReturnType, f.OCamlSelf())
+
+	return fmt.Sprintf("%s @-> returning %s", strings.Join(r, " @-> "), ret)
+}
+
+// OCamlSignature is the type of the routine in the module type, with float arrays as bigarrays of elt.
+func (f *funcDef) OCamlSignature() string {
+	r := []string{}
+	for _, p := range f.args {
+		_, t, _ := getOCamlParamType(p.typeName, f.OCamlSelf(), "elt")
+		r = append(r, t)
+	}
+	if len(r) == 0 {
+		r = append(r, "unit")
+	}
+	_, ret := getOCamlReturnType(f.ReturnType, f.OCamlSelf())
+
+	return fmt.Sprintf("%s -> %s", strings.Join(r, " -> "), ret)
+}
+
+var ocamlKeywords = map[string]struct{}{
+	"and": {}, "as": {}, "assert": {}, "begin": {}, "class": {}, "constraint": {}, "do": {}, "done": {}, "downto": {},
+	"else": {}, "end": {}, "exception": {}, "external": {}, "false": {}, "for": {}, "fun": {}, "function": {},
+	"functor": {}, "if": {}, "in": {}, "include": {}, "inherit": {}, "initializer": {}, "lazy": {}, "let": {},
+	"match": {}, "method": {}, "module": {}, "mutable": {}, "new": {}, "nonrec": {}, "object": {}, "of": {},
+	"open": {}, "or": {}, "private": {}, "rec": {}, "sig": {}, "struct": {}, "then": {}, "to": {}, "true": {},
+	"try": {}, "type": {}, "val": {}, "virtual": {}, "when": {}, "while": {}, "with": {},
+}
+
+// ocamlParamName makes the c parameter name a valid ocaml variable name.
+func ocamlParamName(name string) string {
+	name = lowerLeading(name)
+	if _, isKeyword := ocamlKeywords[name]; isKeyword {
+		return name + "_"
+	}
+
+	return name
+}
+
+// OCamlParams are the parameters of the implementation in the module.
+func (f *funcDef) OCamlParams() string {
+	if len(f.args) == 0 {
+		return "()"
+	}
+
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, ocamlParamName(p.name))
+	}
+
+	return strings.Join(r, " ")
+}
+
+// OCamlCallParams are the arguments forwarded to the foreign function, with bigarrays converted to pointers.
+func (f *funcDef) OCamlCallParams() string {
+	if len(f.args) == 0 {
+		return "()"
+	}
+
+	r := []string{}
+	for _, p := range f.args {
+		_, _, isArray := getOCamlParamType(p.typeName, f.OCamlSelf(), "")
+		if isArray {
+			r = append(r, fmt.Sprintf("(bigarray_start genarray %s)", ocamlParamName(p.name)))
+		} else {
+			r = append(r, ocamlParamName(p.name))
+		}
+	}
+
+	return strings.Join(r, " ")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"modernc.org/cc/v4"
+)
+
+// headerJobs is the number of the headers translated at once when several are read, such as mkl_cblas.h, mkl_lapacke.h and mkl_vml.h of --headers,
+// each in its own translation unit. With 1, the headers are read one after another in one translation unit, for the headers using the macros of the ones before them.
+var headerJobs = runtime.NumCPU()
+
+// translateSources translates the header of sources, with only the declarations of the routines names, unless names is nil.
+func translateSources(compiler *cc.Config, sources []cc.Source, names []string, key string) (*cc.AST, error) {
+	if names != nil {
+		// the declarations the prefilter keeps may be wrong for the headers it cannot read, which are read as a whole instead
+		ccast, err := translatePrefiltered(compiler, sources, names, key)
+		if err == nil {
+			return ccast, nil
+		}
+		debugf("failed to read the header with only the routines of the function list, reading all of it: %v", err)
+	}
+
+	return cc.Translate(compiler, sources)
+}
+
+// translateHeaders translates each of headers after the predefined sources in its own translation unit, --jobs of them at once,
+// and merges them as one, in the order of the headers. It fails if any of the headers cannot be read alone,
+// such as lapack.h using the types of a header before it, which are then read together instead.
+func translateHeaders(compiler *cc.Config, predefined []cc.Source, headers []string, names []string, key string) (*cc.AST, error) {
+	asts := make([]*cc.AST, len(headers))
+	errs := make([]error, len(headers))
+	jobs := make(chan struct{}, headerJobs)
+	var wg sync.WaitGroup
+	for i, h := range headers {
+		wg.Add(1)
+		go func(i int, h string) {
+			defer wg.Done()
+			jobs <- struct{}{}
+			defer func() { <-jobs }()
+
+			config := *compiler
+			sources := append(append([]cc.Source{}, predefined...), cc.Source{Name: h})
+			asts[i], errs[i] = translateSources(&config, sources, names, key+"\n"+h)
+		}(i, h)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", headers[i], err)
+		}
+	}
+
+	return mergeASTs(asts), nil
+}
+
+// mergeASTs merges the translation units of asts into the first of them, as if they were read one after another.
+// The declarations and the macros of the files already read by an earlier one, such as mkl_types.h included by all the headers of mkl and the builtins,
+// are left out of the later ones, so each is declared once as when the headers are read together.
+func mergeASTs(asts []*cc.AST) *cc.AST {
+	merged := asts[0]
+	read := declaredFiles(merged)
+	decls := []*cc.ExternalDeclaration{}
+	for tu := merged.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+		decls = append(decls, tu.ExternalDeclaration)
+	}
+
+	for _, ast := range asts[1:] {
+		for tu := ast.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+			if !read[tu.ExternalDeclaration.Position().Filename] {
+				decls = append(decls, tu.ExternalDeclaration)
+			}
+		}
+		for name, nodes := range ast.Scope.Nodes {
+			for _, n := range nodes {
+				if !read[n.Position().Filename] {
+					merged.Scope.Nodes[name] = append(merged.Scope.Nodes[name], n)
+				}
+			}
+		}
+		for name, m := range ast.Macros {
+			if _, defined := merged.Macros[name]; !defined {
+				merged.Macros[name] = m
+			}
+		}
+		for file := range declaredFiles(ast) {
+			read[file] = true
+		}
+	}
+
+	merged.TranslationUnit = nil
+	for i := len(decls) - 1; i >= 0; i-- {
+		merged.TranslationUnit = &cc.TranslationUnit{ExternalDeclaration: decls[i], TranslationUnit: merged.TranslationUnit}
+	}
+
+	return merged
+}
+
+// declaredFiles are the files the declarations of ast are read from, the headers and the predefined sources.
+func declaredFiles(ast *cc.AST) map[string]bool {
+	files := make(map[string]bool)
+	for tu := ast.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+		files[tu.ExternalDeclaration.Position().Filename] = true
+	}
+
+	return files
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	paramNamesPath = ""
+	// userParamNames are the new names of the parameters by the routines, from --param-names.
+	userParamNames = map[string]map[string]string{}
+)
+
+// readParamNames reads the new names of the parameters from the json file of --param-names, keyed by the routines
+// and then by the names of the parameters, such as
+//
+//	{"vslDeleteStream": {"p0": "stream"}, "v*RngGaussian": {"r": "out"}}
+//
+// The parameters without names in the header are p0, p1 and so on by their positions.
+// The routines are the names in the header, or the entries with wildcards for all the routines of the entries.
+func readParamNames(path string) map[string]map[string]string {
+	byEntry := make(map[string]map[string]string)
+	if err := json.Unmarshal(readFile(path), &byEntry); err != nil {
+		failf(exitInput, "failed to read the parameter names %s: %v", path, err)
+	}
+
+	r := make(map[string]map[string]string)
+	for entry, names := range byEntry {
+		if !strings.ContainsAny(entry, wildcardChars()) {
+			r[entry] = names
+			continue
+		}
+		for name := range expandName(entry) {
+			r[name] = names
+		}
+	}
+
+	return r
+}
+
+// builtinParamNames are the names of the parameters by their positions for the routines declaring them without names,
+// which are the ones of mkl_vsl.h and mkl_dfti.h, since the cblas and lapacke routines are declared with names.
+// ? is s and d for the single and double precision routines. The names follow the mkl reference.
+var builtinParamNames = expandPrecisions(map[string][]string{
+	"vslNewStream":              {"stream", "brng", "seed"},
+	"vslNewStreamEx":            {"stream", "brng", "n", "params"},
+	"vslDeleteStream":           {"stream"},
+	"vslCopyStream":             {"newstream", "srcstream"},
+	"vslCopyStreamState":        {"deststream", "srcstream"},
+	"vslSkipAheadStream":        {"stream", "nskip"},
+	"vslLeapfrogStream":         {"stream", "k", "nstreams"},
+	"vslGetStreamStateBrng":     {"stream"},
+	"v?RngUniform":              {"method", "stream", "n", "r", "a", "b"},
+	"v?RngGaussian":             {"method", "stream", "n", "r", "a", "sigma"},
+	"v?RngGaussianMV":           {"method", "stream", "n", "r", "dimen", "mstorage", "a", "t"},
+	"v?RngExponential":          {"method", "stream", "n", "r", "a", "beta"},
+	"v?RngLaplace":              {"method", "stream", "n", "r", "a", "beta"},
+	"v?RngWeibull":              {"method", "stream", "n", "r", "alpha", "a", "beta"},
+	"v?RngCauchy":               {"method", "stream", "n", "r", "a", "beta"},
+	"v?RngRayleigh":             {"method", "stream", "n", "r", "a", "beta"},
+	"v?RngLognormal":            {"method", "stream", "n", "r", "a", "sigma", "b", "beta"},
+	"v?RngGumbel":               {"method", "stream", "n", "r", "a", "beta"},
+	"v?RngGamma":                {"method", "stream", "n", "r", "alpha", "a", "beta"},
+	"v?RngBeta":                 {"method", "stream", "n", "r", "p", "q", "a", "beta"},
+	"v?RngChiSquare":            {"method", "stream", "n", "r", "v"},
+	"DftiCreateDescriptor_?_1d": {"desc_handle", "domain", "length"},
+	"DftiCreateDescriptor_?_md": {"desc_handle", "domain", "dimension", "lengths"},
+	"DftiCommitDescriptor":      {"desc_handle"},
+	"DftiComputeForward":        {"desc_handle", "x_inout"},
+	"DftiComputeBackward":       {"desc_handle", "x_inout"},
+	"DftiCopyDescriptor":        {"desc_handle_original", "desc_handle_copy"},
+	"DftiFreeDescriptor":        {"desc_handle"},
+	"DftiErrorMessage":          {"status"},
+})
+
+// expandPrecisions replaces the ? in the routines of m with s and d.
+func expandPrecisions(m map[string][]string) map[string][]string {
+	r := make(map[string][]string)
+	for name, params := range m {
+		if !strings.Contains(name, "?") {
+			r[name] = params
+			continue
+		}
+		for _, letter := range []string{"s", "d"} {
+			r[strings.Replace(name, "?", letter, 1)] = params
+		}
+	}
+
+	return r
+}
+
+// renameParams gives the parameters of routine name without names in the header their names from the built-in ones,
+// and then the parameters from --param-names their names.
+func renameParams(name string, args []funcArg) {
+	// the parameters are looked up by their names in the header, so the names can be swapped
+	original := make([]string, 0, len(args))
+	for _, arg := range args {
+		original = append(original, arg.name)
+	}
+
+	for i, param := range builtinParamNames[name] {
+		if i < len(args) && args[i].name == fmt.Sprintf("p%d", i) {
+			args[i].name = param
+		}
+	}
+
+	names, found := userParamNames[name]
+	if !found {
+		return
+	}
+
+	for from, to := range names {
+		i := slices.Index(original, from)
+		if i < 0 {
+			failf(exitInput, "%s has no parameter %s to rename to %s in --param-names", name, from, to)
+		}
+		args[i].name = to
+	}
+}
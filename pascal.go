@@ -0,0 +1,153 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed pascal.tmpl
+var pascalTmplText string
+
+var (
+	forPascal      = false
+	pascalUnitName = "MKLRoutines"
+	pascalLibrary  = "mkl_rt"
+)
+
+func (*tmplInput) PascalUnitName() string {
+	return pascalUnitName
+}
+
+func (*tmplInput) PascalLibrary() string {
+	return pascalLibrary
+}
+
+// PascalSelf is the pascal type of the float type.
+func (f *funcDef) PascalSelf() string {
+	if f.is32() {
+		return "Single"
+	}
+	return "Double"
+}
+
+// getPascalParamType returns the pascal type of c type t, with self as the float type.
+// The types are available in both free pascal and delphi. Types that are not known are passed as Pointer.
+func getPascalParamType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.pascalType
+	}
+
+	switch t {
+	case "VSLStreamStatePtr *", "DFTI_DESCRIPTOR_HANDLE *":
+		return "PPointer"
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		return "P" + self
+	case "double", "float", "const double", "const float":
+		return self
+	case "char", "const char":
+		return "AnsiChar"
+	case "const char *":
+		return "PAnsiChar"
+	case "int *", "const int *":
+		return "PLongInt"
+	case "int64_t *", "const int64_t *":
+		return "PInt64"
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "LongInt"
+	}
+
+	switch {
+	case isEnum(t):
+		return "LongInt"
+	case isStruct(t):
+		return structTag(t)
+	}
+
+	return "Pointer"
+}
+
+// PascalFields are the fields of the record, which has the same layout as the c struct.
+func (s *structDef) PascalFields() []string {
+	r := []string{}
+	for _, field := range s.fields {
+		r = append(r, fmt.Sprintf("%s: %s;", pascalParamName(field.name), getPascalParamType(field.typeName, fieldSelf(field, "Double", "Single"))))
+	}
+
+	return r
+}
+
+func getPascalReturnType(t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.pascalType
+	}
+
+	switch t {
+	case "void":
+		return ""
+	case "float", "double":
+		return self
+	}
+	if isEnum(t) {
+		return "LongInt"
+	}
+	if isStruct(t) {
+		return structTag(t)
+	}
+
+	return "Pointer"
+}
+
+var pascalKeywords = map[string]struct{}{
+	"and": {}, "array": {}, "as": {}, "asm": {}, "begin": {}, "case": {}, "class": {}, "const": {}, "constructor": {},
+	"destructor": {}, "div": {}, "do": {}, "downto": {}, "else": {}, "end": {}, "except": {}, "exports": {}, "file": {},
+	"finalization": {}, "finally": {}, "for": {}, "function": {}, "goto": {}, "if": {}, "implementation": {}, "in": {},
+	"inherited": {}, "initialization": {}, "inline": {}, "interface": {}, "is": {}, "label": {}, "library": {}, "mod": {},
+	"nil": {}, "not": {}, "object": {}, "of": {}, "on": {}, "operator": {}, "or": {}, "out": {}, "packed": {},
+	"procedure": {}, "program": {}, "property": {}, "raise": {}, "record": {}, "repeat": {}, "resourcestring": {},
+	"set": {}, "shl": {}, "shr": {}, "string": {}, "then": {}, "threadvar": {}, "to": {}, "try": {}, "type": {},
+	"unit": {}, "until": {}, "uses": {}, "var": {}, "while": {}, "with": {}, "xor": {},
+}
+
+// pascalParamName escapes the c parameter name with & if it is a pascal keyword.
+func pascalParamName(name string) string {
+	if _, isKeyword := pascalKeywords[strings.ToLower(name)]; isKeyword {
+		return "&" + name
+	}
+
+	return name
+}
+
+// PascalHeader is the function or procedure heading of the routine called name, with self as the float type.
+func (f *funcDef) PascalHeader(name string, self string) string {
+	kind := "function"
+	ret := getPascalReturnType(f.ReturnType, self)
+	if ret == "" {
+		kind = "procedure"
+	} else {
+		ret = ": " + ret
+	}
+
+	if len(f.args) == 0 {
+		return fmt.Sprintf("%s %s%s", kind, name, ret)
+	}
+
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, fmt.Sprintf("%s: %s", pascalParamName(p.name), getPascalParamType(p.typeName, self)))
+	}
+
+	return fmt.Sprintf("%s %s(%s)%s", kind, name, strings.Join(r, "; "), ret)
+}
+
+// PascalCallArgs are the arguments forwarded to the external routine.
+func (f *funcDef) PascalCallArgs() string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, pascalParamName(p.name))
+	}
+
+	return strings.Join(r, ", ")
+}
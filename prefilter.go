@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// prefilter parses only the declarations of the routines of the function list, the types and the constants of the header,
+// dropping the declarations of the other routines after preprocessing it, which is much faster for the lists of a few routines.
+var prefilter = true
+
+// prefilterLineMarker marks the lines of the headers the declarations may start on, as __gen_mkl_wrapper_line_<header>_<line>,
+// which the preprocessor keeps as it is, for the lines of the declarations kept.
+const prefilterLineMarker = "__gen_mkl_wrapper_line_"
+
+// prefilterRoutinesMarker comes before the routines of the function list after the headers,
+// which the preprocessor replaces with the routines they are macros of, such as MKL_Get_Max_Threads of mkl_get_max_threads.
+const prefilterRoutinesMarker = "__gen_mkl_wrapper_routines__"
+
+// declarationWords are the words before a '(' that are not the names of the routines, such as __attribute__((deprecated)),
+// which are skipped for the name, and the keywords, such as void (*f)(void), which keep the declaration as it names no routine.
+var declarationWords = map[string]bool{
+	"__attribute__": true, "__attribute": true, "__declspec": true, "__asm__": true, "__asm": true, "asm": true,
+	"_Alignas": true, "alignas": true, "__typeof__": true, "__typeof": true, "typeof": true,
+	"void": false, "char": false, "short": false, "int": false, "long": false, "float": false, "double": false,
+	"signed": false, "unsigned": false, "_Bool": false, "_Complex": false, "const": false, "volatile": false,
+	"restrict": false, "__restrict": false, "struct": false, "union": false, "enum": false, "extern": false,
+	"static": false, "inline": false, "__inline": false, "typedef": false, "_Static_assert": false, "static_assert": false,
+}
+
+// prefilterNames are the routines of function list content, which the prefilter keeps the declarations of,
+// or nil to parse all of the header, without --prefilter or a function list.
+func prefilterNames(content string) []string {
+	if !prefilter || content == "" {
+		return nil
+	}
+
+	f := readFuncList(content)
+	f.addStreamRoutines(nil)
+	f.addDescriptorRoutines(nil)
+	names := make([]string, 0, len(f.names))
+	for name := range f.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// translatePrefiltered translates the header of sources with only the declarations of the routines names, the types and the macros.
+// The header is preprocessed with the lines the declarations start on marked, to find the lines of the declarations kept,
+// then parsed with only these lines and the directives, so the declarations kept and the macros are read as they are with all of the header.
+// The lines kept are read from the cache of --header-cache when the headers are the same as when they were cached, which skips preprocessing them.
+func translatePrefiltered(compiler *cc.Config, sources []cc.Source, names []string, key string) (*cc.AST, error) {
+	cacheFile := headerCacheFile(key, compiler)
+	kept, cached := loadCachedHeader(cacheFile)
+	if !cached {
+		var paths []string
+		var err error
+		if kept, paths, err = preprocessKept(compiler, sources, names); err != nil {
+			return nil, err
+		}
+		storeCachedHeader(cacheFile, paths, kept)
+	}
+
+	parser := *compiler
+	parser.FS = &prefilterFS{kept: kept}
+
+	return cc.Translate(&parser, sources)
+}
+
+// preprocessKept preprocesses the header of sources with the lines the declarations start on marked,
+// for the lines of the declarations of the routines names, the types and the macros, and the headers read.
+func preprocessKept(compiler *cc.Config, sources []cc.Source, names []string) (map[string][]lineRange, []string, error) {
+	marked := &prefilterFS{}
+	preprocessor := *compiler
+	preprocessor.FS = marked
+	routines := cc.Source{Name: "<routines>", Value: prefilterRoutinesMarker + " " + strings.Join(names, " ") + "\n"}
+	var b bytes.Buffer
+	if err := cc.Preprocess(&preprocessor, append(slices.Clone(sources), routines), &b); err != nil {
+		return nil, nil, err
+	}
+
+	out := b.Bytes()
+	keep := make(map[string]bool)
+	// want are the routines by the index of their names, as named or as the routines they are macros of,
+	// or nil if the macros do not name one routine each, so the routines found are not known.
+	var want map[string]int
+	if i := bytes.LastIndex(out, []byte(prefilterRoutinesMarker)); i >= 0 {
+		routines := strings.Fields(string(out[i+len(prefilterRoutinesMarker):]))
+		if len(routines) == len(names) && len(names) > 0 {
+			want = make(map[string]int)
+		}
+		for k, name := range routines {
+			keep[name] = true
+			if want != nil {
+				want[name], want[names[k]] = k, k
+			}
+		}
+		out = out[:i]
+	}
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	return keptLines(out, marked.paths, keep, want, len(names)), marked.paths, nil
+}
+
+// prefilterFS opens the headers as headerFS does, with the markers of the lines the declarations may start on for preprocessing,
+// or with only the directives and the lines of the declarations kept for parsing.
+type prefilterFS struct {
+	// kept are the lines of the declarations kept by the headers, or nil to mark the lines.
+	kept map[string][]lineRange
+	// paths are the headers opened, which the markers name by their index.
+	paths []string
+}
+
+func (h *prefilterFS) Open(name string) (fs.File, error) {
+	f, err := headerFS{}.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		return f, nil
+	}
+	defer f.Close()
+
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if h.kept != nil {
+		kept := make([]bool, bytes.Count(src, []byte("\n"))+2)
+		for _, r := range h.kept[name] {
+			for l := r.from; l < len(kept) && (l <= r.to || r.to == 0); l++ {
+				kept[l] = true
+			}
+		}
+		return &prefilteredFile{Reader: bytes.NewReader(rewriteHeader(src, -1, kept)), info: fi}, nil
+	}
+	index := slices.Index(h.paths, name)
+	if index < 0 {
+		index = len(h.paths)
+		h.paths = append(h.paths, name)
+	}
+
+	return &prefilteredFile{Reader: bytes.NewReader(rewriteHeader(src, index, nil)), info: fi}, nil
+}
+
+// prefilteredFile is a header rewritten by prefilterFS.
+type prefilteredFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *prefilteredFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (*prefilteredFile) Close() error { return nil }
+
+// lineRange is the lines from and to of a header, to the end of it if to is 0.
+type lineRange struct {
+	from, to int
+}
+
+// rewriteHeader is header src with the marker of index before the first character of each line outside of the comments and the directives,
+// if it follows a ';', '{' or '}' as a declaration does. With index -1, it is src with only the directives and the lines kept,
+// with their comments as spaces, and the other lines empty, so the lines are the same as in src.
+func rewriteHeader(src []byte, index int, kept []bool) []byte {
+	var b bytes.Buffer
+	marking := index >= 0
+	line := 1
+	last := byte(';')
+	inComment, inLineComment, isDirective, lineHasCode, logicalHasCode := false, false, false, false, false
+	keeping := !marking && len(kept) > 1 && kept[1]
+	emit := func(p []byte) {
+		if marking || isDirective || keeping {
+			b.Write(p)
+		}
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		next := byte(0)
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+		switch {
+		case c == '\n':
+			b.WriteByte(c)
+			line++
+			lineHasCode = false
+			inLineComment = false
+			if !inComment && (i == 0 || src[i-1] != '\\') && !(i > 1 && src[i-1] == '\r' && src[i-2] == '\\') {
+				isDirective = false
+				logicalHasCode = false
+			}
+			keeping = !marking && line < len(kept) && kept[line]
+		case inComment:
+			if marking || isDirective {
+				b.WriteByte(c)
+			}
+			if c == '*' && next == '/' {
+				if marking || isDirective {
+					b.WriteByte(next)
+				}
+				i++
+				inComment = false
+			}
+		case inLineComment:
+			if marking || isDirective {
+				b.WriteByte(c)
+			}
+		case c == '/' && (next == '*' || next == '/'):
+			// the comments of the declarations kept are a space, as the preprocessor reads them, not to end in the lines left out
+			switch {
+			case marking || isDirective:
+				b.Write(src[i : i+2])
+			case keeping:
+				b.WriteByte(' ')
+			}
+			i++
+			inComment, inLineComment = next == '*', next == '/'
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' || c == '\\':
+			emit(src[i : i+1])
+		default:
+			if c == '#' && !logicalHasCode {
+				isDirective = true
+			}
+			if marking && !isDirective && !lineHasCode && (last == ';' || last == '{' || last == '}') {
+				fmt.Fprintf(&b, "%s%d_%d ", prefilterLineMarker, index, line)
+			}
+			lineHasCode, logicalHasCode = true, true
+			start := i
+			// the string and character literals, such as "/*", up to the end of the line if they are not closed
+			if c == '"' || c == '\'' {
+				for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+					if src[i] == '\\' && i+1 < len(src) && src[i+1] != '\n' {
+						i++
+					}
+				}
+				if i == len(src) || src[i] == '\n' {
+					i--
+				}
+			}
+			emit(src[start : i+1])
+			if !isDirective {
+				last = c
+			}
+		}
+	}
+
+	return b.Bytes()
+}
+
+// keptLines are the lines of the declarations of preprocessed header out by the headers of paths, without the ones of the routines not in keep
+// and the definitions of the functions, which are never selected. The types, the variables and the declarations naming no routine,
+// such as of the pointers to the functions, are all kept. A declaration is kept from the line it starts on to the line before the next marker of its header,
+// which the next declaration of the header starts on, or to the end of the header, with the lines of the conditions not met and the directives in between.
+// Once the routines of all the routines entries of want are kept, the declarations after them are dropped, and only the directives of the rest of the headers are read,
+// as the routines only use the types declared before them, and the constants of the outputs are macros.
+func keptLines(out []byte, paths []string, keep map[string]bool, want map[string]int, routines int) map[string][]lineRange {
+	type marker struct {
+		file string
+		line int
+	}
+	markers := []marker{}
+	type keptDecl struct {
+		marker
+		// start is the index of the marker of the declaration, and next of the first marker after it.
+		start, next int
+	}
+	decls := []keptDecl{}
+
+	start := 0
+	inDecl := false
+	depth := 0
+	prev, name := "", ""
+	named, keepDecl, isBody := false, false, false
+	total, dropped := 0, 0
+	found := make(map[int]bool)
+	end := func() {
+		total++
+		switch {
+		case want != nil && len(found) == routines:
+			dropped++
+		case isBody || named && name != "" && !keepDecl && !keep[name]:
+			dropped++
+		default:
+			decls = append(decls, keptDecl{marker: markers[start], start: start, next: len(markers)})
+			if k, wanted := want[name]; wanted && named && !keepDecl {
+				found[k] = true
+			}
+		}
+		inDecl = false
+		depth, prev, name = 0, "", ""
+		named, keepDecl, isBody = false, false, false
+	}
+
+	for i := 0; i < len(out); {
+		c := out[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v' {
+			i++
+			continue
+		}
+
+		from := i
+		switch {
+		case isWordByte(c):
+			for i < len(out) && isWordByte(out[i]) {
+				i++
+			}
+			// the prefixes of the wide and unicode literals, such as L"name"
+			if i < len(out) && (out[i] == '"' || out[i] == '\'') && slices.Contains([]string{"L", "u", "U", "u8"}, string(out[from:i])) {
+				i = skipLiteral(out, i)
+			}
+		case c == '"' || c == '\'':
+			i = skipLiteral(out, i)
+		default:
+			i++
+		}
+		tok := string(out[from:i])
+
+		if m, isMarker := strings.CutPrefix(tok, prefilterLineMarker); isMarker {
+			index, at, _ := strings.Cut(m, "_")
+			header, _ := strconv.Atoi(index)
+			line, _ := strconv.Atoi(at)
+			markers = append(markers, marker{file: paths[header], line: line})
+			if !inDecl {
+				start = len(markers) - 1
+			}
+			continue
+		}
+		// the declarations of the predefined macros and the builtins before the headers are parsed from their own sources
+		if len(markers) == 0 {
+			continue
+		}
+
+		inDecl = true
+		switch tok {
+		case "typedef":
+			if depth == 0 {
+				keepDecl = true
+			}
+		case "(":
+			if depth == 0 && !named {
+				skipped, isWord := declarationWords[prev]
+				switch {
+				case skipped:
+				case isWord || prev == "" || !isWordByte(prev[0]) || '0' <= prev[0] && prev[0] <= '9':
+					named = true
+				default:
+					named, name = true, prev
+				}
+			}
+			depth++
+		case "{":
+			if depth == 0 && prev == ")" {
+				isBody = true
+			}
+			depth++
+		case "[":
+			depth++
+		case ")", "]":
+			depth--
+		case "}":
+			depth--
+			if depth == 0 && isBody {
+				end()
+				continue
+			}
+		case "=", ",":
+			if depth == 0 {
+				keepDecl = true
+			}
+		case ";":
+			if depth == 0 {
+				end()
+				continue
+			}
+		}
+		prev = tok
+	}
+	if inDecl {
+		isBody, keepDecl = false, true
+		end()
+	}
+	if want != nil && len(found) == routines {
+		debugf("found all the %d routines of the function list, dropping the declarations after them", routines)
+	}
+	debugf("dropped %d of the %d declarations of the header before parsing it", dropped, total)
+
+	// the next marker of the same header after each marker
+	nextInFile := make([]int, len(markers))
+	following := make(map[string]int)
+	for k := len(markers) - 1; k >= 0; k-- {
+		nextInFile[k] = len(markers)
+		if n, found := following[markers[k].file]; found {
+			nextInFile[k] = n
+		}
+		following[markers[k].file] = k
+	}
+
+	kept := make(map[string][]lineRange)
+	for _, d := range decls {
+		r := lineRange{from: d.line}
+		// the first marker of the header after the declaration, which is in the same inclusion of the header if it is after the declaration
+		k := d.start
+		for k < d.next {
+			k = nextInFile[k]
+		}
+		if k < len(markers) && markers[k].line > d.line {
+			r.to = markers[k].line - 1
+		}
+		kept[d.file] = append(kept[d.file], r)
+	}
+
+	return kept
+}
+
+// skipLiteral is the index after the string or character literal starting at i of out.
+func skipLiteral(out []byte, i int) int {
+	quote := out[i]
+	for i++; i < len(out) && out[i] != quote; i++ {
+		if out[i] == '\\' {
+			i++
+		}
+	}
+
+	return min(i+1, len(out))
+}
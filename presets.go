@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// presets are the function lists of --preset, for the common routines without knowing their names in mkl.
+// They are written with the * wildcard of the single and double precision routines.
+var presets = map[string][]string{
+	"blas-level1": {
+		"cblas_*asum",
+		"cblas_*axpy",
+		"cblas_*copy",
+		"cblas_*dot",
+		"cblas_*nrm2",
+		"cblas_*rot",
+		"cblas_*scal",
+		"cblas_*swap",
+		"cblas_i*amax",
+		"cblas_i*amin",
+	},
+	"blas-level2": {
+		"cblas_*gemv",
+		"cblas_*ger",
+		"cblas_*symv",
+		"cblas_*syr",
+		"cblas_*trmv",
+		"cblas_*trsv",
+	},
+	"blas-level3": {
+		"cblas_*gemm",
+		"cblas_*symm",
+		"cblas_*syrk",
+		"cblas_*syr2k",
+		"cblas_*trmm",
+		"cblas_*trsm",
+	},
+	"lapack-drivers": {
+		"LAPACKE_*gesv",
+		"LAPACKE_*posv",
+		"LAPACKE_*gels",
+		"LAPACKE_*gesvd",
+		"LAPACKE_*syev",
+		"LAPACKE_*syevd",
+		"LAPACKE_*geev",
+	},
+	"lapack-factorizations": {
+		"LAPACKE_*getrf",
+		"LAPACKE_*getrs",
+		"LAPACKE_*getri",
+		"LAPACKE_*potrf",
+		"LAPACKE_*potrs",
+		"LAPACKE_*potri",
+		"LAPACKE_*geqrf",
+		"LAPACKE_*orgqr",
+	},
+	"vml-basic": {
+		"v*Add",
+		"v*Sub",
+		"v*Mul",
+		"v*Div",
+		"v*Sqr",
+		"v*Sqrt",
+		"v*Abs",
+		"v*Exp",
+		"v*Ln",
+		"v*Pow",
+		"v*Sin",
+		"v*Cos",
+	},
+	"rng-basic": {
+		"v*RngUniform",
+		"v*RngGaussian",
+		"v*RngExponential",
+		"VSL_BRNG_MT19937",
+		"VSL_BRNG_MCG31",
+		"VSL_RNG_METHOD_UNIFORM_STD",
+		"VSL_RNG_METHOD_GAUSSIAN_ICDF",
+		"VSL_RNG_METHOD_EXPONENTIAL_ICDF",
+	},
+}
+
+// presetNames are the presets of --preset, which are added to the function list.
+var presetNames []string
+
+// presetNameList is the names of the presets, for the help of --preset.
+func presetNameList() string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return strings.Join(names, ", ")
+}
+
+// presetRoutines are the entries of the function list of the presets of --preset.
+func presetRoutines() []string {
+	if len(presetNames) > 0 && !strings.Contains(wildcardChars(), "*") {
+		failf(exitInput, "the presets are written with the * wildcard, which is renamed or not in --wildcards")
+	}
+
+	r := []string{}
+	for _, name := range presetNames {
+		routines, found := presets[name]
+		if !found {
+			failf(exitInput, "%s is not a preset, the presets are %s", name, presetNameList())
+		}
+		r = append(r, routines...)
+	}
+
+	return r
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// blasProvider is a blas other than mkl, such as cublas or blis, read instead of mkl.h from where it is installed.
+type blasProvider struct {
+	// name is the flag selecting it, such as --cublas.
+	name string
+	// rootEnv is the environment variable of the root of the installation, which is defaultRoot without it.
+	rootEnv     string
+	defaultRoot string
+	// include is the header under the include directory of the root, which the outputs include.
+	include string
+	// macros are defined when reading the header and in the generated c code, such as the platform of hip.
+	macros []string
+	// upperComplex selects the complex routines with % by the upper case Z and C, such as cublasZgemm_v2.
+	upperComplex bool
+	// prefix and suffix are trimmed from the names of the wrappers of the entries with wildcards,
+	// so cublas#gemm_v2, rocblas_*gemm, hipblas#gemm, bli_*gemm and fftw*_execute are gemm and execute.
+	prefix string
+	suffix string
+	// entryPrefix of the entries of the list is read as prefix, so the list of cblas_*gemm selects bli_*gemm of blis.
+	entryPrefix string
+	// letters are the letters of the wildcards by their characters, in the order of the types, such as the empty letter and f
+	// of fftw_execute and fftwf_execute for fftw*_execute.
+	letters map[string][]string
+	// precisionTypes is true when the routines take the types of their precision, such as the plans of fftw,
+	// which only the traits of rust, the overloads of c++, _Generic of c and F of go dispatch on.
+	precisionTypes bool
+
+	selected bool
+}
+
+// blasProviders are the blases other than mkl, each selected by the flag of its name.
+var blasProviders = []*blasProvider{
+	{
+		name: "cublas", rootEnv: "CUDA_PATH", defaultRoot: "/usr/local/cuda", include: "cublas_v2.h",
+		upperComplex: true, prefix: "cublas", suffix: "_v2",
+	},
+	{
+		name: "rocblas", rootEnv: "ROCM_PATH", defaultRoot: "/opt/rocm", include: "rocblas/rocblas.h",
+		macros: []string{"__HIP_PLATFORM_AMD__"}, prefix: "rocblas_",
+	},
+	{
+		name: "hipblas", rootEnv: "ROCM_PATH", defaultRoot: "/opt/rocm", include: "hipblas/hipblas.h",
+		macros: []string{"__HIP_PLATFORM_AMD__"}, upperComplex: true, prefix: "hipblas",
+	},
+	{
+		name: "blis", rootEnv: "BLIS_PREFIX", defaultRoot: "/usr/local", include: "blis/blis.h",
+		prefix: "bli_", entryPrefix: "cblas_",
+	},
+	{
+		name: "fftw", rootEnv: "FFTW_PREFIX", defaultRoot: "/usr/local", include: "fftw3.h",
+		prefix: "fftw_", letters: map[string][]string{"*": {"", "f"}, "%": {"", "f"}}, precisionTypes: true,
+	},
+}
+
+// addProviderFlags adds the flags selecting the blases other than mkl, which exclude each other and --accelerate.
+func addProviderFlags(cmd *cobra.Command) {
+	names := []string{"accelerate"}
+	for _, g := range blasProviders {
+		help := fmt.Sprintf("read %s of the include directory of %s, or %s, without --mkl-header, and name the wrappers of the entries with wildcards without the prefix %s",
+			g.include, g.rootEnv, g.defaultRoot, g.prefix)
+		if g.suffix != "" {
+			help += " and the suffix " + g.suffix
+		}
+		if g.upperComplex {
+			help += ", with % in place of the upper case Z/C"
+		}
+		if g.entryPrefix != "" {
+			help += ", and read the entries of " + g.entryPrefix + " as " + g.prefix
+		}
+		if g.letters != nil {
+			help += ", with * and % in place of the precision, such as fftw*_execute for fftw_execute and fftwf_execute"
+		}
+		cmd.PersistentFlags().BoolVar(&g.selected, g.name, g.selected, help)
+		names = append(names, g.name)
+	}
+	cmd.MarkFlagsMutuallyExclusive(names...)
+}
+
+// selectedProvider is the blas other than mkl selected by its flag, or nil.
+func selectedProvider() *blasProvider {
+	for _, g := range blasProviders {
+		if g.selected {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// includeDir is the include directory of the installation, such as /usr/local/cuda/include,
+// which the headers are searched in, such as hip/hip_runtime_api.h of hipblas.h.
+func (g *blasProvider) includeDir() string {
+	root := os.Getenv(g.rootEnv)
+	if root == "" {
+		root = g.defaultRoot
+	}
+
+	return filepath.Join(root, "include")
+}
+
+// entryName is the entry of the list read with the prefix of the blas in place of entryPrefix,
+// such as bli_*gemm for cblas_*gemm, which also leaves out the routines of bli_*gemm for !cblas_*gemm.
+func (g *blasProvider) entryName(entry string) string {
+	if g.entryPrefix == "" {
+		return entry
+	}
+	if rest, found := strings.CutPrefix(entry, g.entryPrefix); found {
+		return g.prefix + rest
+	}
+
+	return entry
+}
+
+// wrapperName is the name of the wrappers of an entry with wildcards, with the prefix and the suffix of the blas trimmed,
+// unless nothing is left of it.
+func (g *blasProvider) wrapperName(name string) string {
+	if trimmed := strings.TrimSuffix(strings.TrimPrefix(name, g.prefix), g.suffix); trimmed != "" {
+		return trimmed
+	}
+
+	return name
+}
+
+// upperComplexWildcard makes % select the routines on complex types by Z and C, as cublas and hipblas name them.
+func upperComplexWildcard() {
+	for i, w := range wildcards {
+		if w.char != "%" {
+			continue
+		}
+		letters := []wildcardLetter{}
+		for _, l := range w.letters {
+			l.letter = strings.ToUpper(l.letter)
+			letters = append(letters, l)
+		}
+		wildcards[i].letters = letters
+	}
+}
+
+// replaceLetters replaces the letters of the wildcards with the letters of the blas, keeping their types.
+func (g *blasProvider) replaceLetters() {
+	for i, w := range wildcards {
+		letters, found := g.letters[w.char]
+		if !found {
+			continue
+		}
+		if len(letters) != len(w.letters) {
+			failf(exitInput, "wildcard %s has %d types, and %s has %d letters for it", w.char, len(w.letters), g.name, len(letters))
+		}
+		replaced := []wildcardLetter{}
+		for j, l := range w.letters {
+			l.letter = letters[j]
+			replaced = append(replaced, l)
+		}
+		wildcards[i].letters = replaced
+	}
+}
+
+// checkProviderOutput fails unless the output dispatches on the types of the precision of the routines, such as the plans of fftw,
+// which the other outputs take as pointers, calling the double precision routines when no float argument tells them apart.
+func checkProviderOutput() {
+	provider := selectedProvider()
+	if provider == nil || !provider.precisionTypes {
+		return
+	}
+	if forJulia || forPython || forCffi || forJava || forZig || forFortran || forHaskell || forOCaml || forKotlin ||
+		forLua || forNode || forPascal || forCrystal || forAda || forSwift || forR {
+		failf(exitInput, "--%s generates rust, c++, c or go, which dispatch on the types of the precision of its routines", provider.name)
+	}
+}
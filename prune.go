@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// prune removes the files the previous run recorded in --manifest and this one no longer writes,
+// such as the go file of a group left out of the function list, and reports the wrappers it no longer generates.
+var prune = false
+
+// readManifest is the manifest at path written by the previous run, or nil if there is none yet.
+func readManifest(path string) *manifest {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	orFail(exitInput, err)
+
+	m := &manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		failf(exitInput, "--prune reads the wrappers of the previous run from %s, which is not a manifest: %v", path, err)
+	}
+
+	return m
+}
+
+// pruneStale is the files of the previous manifest prev that current no longer has, which are removed,
+// or emptied between their markers if they have them, keeping the hand-written code around them as --in-place does.
+// The files neither generated by the tool nor with the markers are left as they are. The wrappers prev has and current does not
+// are reported, with a warning if the hand-written code of the output still mentions them.
+func pruneStale(prev *manifest, current *manifest) []generatedFile {
+	if prev == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for _, w := range current.Wrappers {
+		names[w.Name] = true
+	}
+	stale := []string{}
+	for _, w := range prev.Wrappers {
+		if !names[w.Name] {
+			stale = append(stale, w.Name)
+			infof("pruned %s, which the function list no longer selects", w.Name)
+		}
+	}
+	if inPlace && len(stale) > 0 {
+		warnManualWrappers(current.Output, stale)
+	}
+
+	files := []generatedFile{}
+	for _, path := range prev.Files {
+		if slices.Contains(current.Files, path) {
+			continue
+		}
+		existing, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		orFail(exitOutput, err)
+		switch {
+		case bytes.Contains(existing, []byte(regionBegin)):
+			files = append(files, generatedFile{path: path, content: spliceRegion(path, nil), pruned: true})
+		case bannerPattern.Match(existing) || len(existing) == 0:
+			files = append(files, generatedFile{path: path, pruned: true})
+		default:
+			warnf("%s is no longer generated but is not generated by gen-mkl-wrapper either, keeping it", path)
+		}
+	}
+
+	return files
+}
+
+// warnManualWrappers warns about the wrappers of stale that the hand-written code around the markers of the output at path still mentions,
+// such as the ones merged by hand from an earlier output, which --in-place leaves to be removed by hand.
+func warnManualWrappers(path string, stale []string) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	// the wrappers are named as in the function list, which the outputs capitalize as their languages require
+	manual := outsideRegion(existing)
+	for _, name := range stale {
+		if regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(name) + `\b`).Match(manual) {
+			warnf("the hand-written code of %s still mentions %s, which is no longer generated", path, name)
+		}
+	}
+}
+
+// outsideRegion is content without the lines between its markers, the code around them written by hand.
+func outsideRegion(content []byte) []byte {
+	var b bytes.Buffer
+	inRegion := false
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		switch {
+		case bytes.Contains(line, []byte(regionBegin)):
+			inRegion = true
+		case bytes.Contains(line, []byte(regionEnd)):
+			inRegion = false
+		case !inRegion:
+			b.Write(line)
+		}
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed py.tmpl
+var pyTmplText string
+
+var (
+	forPython     = false
+	pythonLibrary = "mkl_rt"
+)
+
+func (*tmplInput) PythonLibrary() string {
+	return pythonLibrary
+}
+
+// getPyParamType returns the ctypes type for c type t, and if the type is the dispatched float type.
+// Types that are not known are passed as c_void_p.
+func getPyParamType(t string, self string) (string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.pyType, false
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return fmt.Sprintf("POINTER(%s)", self), true
+	case "double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("POINTER(%s)", self), true
+	case "double", "float", "const double", "const float":
+		return self, true
+	case "char", "const char":
+		return "c_char", false
+	case "const char *":
+		return "c_char_p", false
+	case "int *", "const int *":
+		return "POINTER(c_int)", false
+	case "int64_t *", "const int64_t *":
+		return "POINTER(c_int64)", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "c_int", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "c_int", false
+	case isStruct(t):
+		return structTag(t), false
+	}
+
+	return "c_void_p", false
+}
+
+// PyFields is the _fields_ of the ctypes structure.
+func (s *structDef) PyFields() string {
+	r := []string{}
+	for _, field := range s.fields {
+		t, _ := getPyParamType(field.typeName, fieldSelf(field, "c_double", "c_float"))
+		r = append(r, fmt.Sprintf("(\"%s\", %s)", field.name, t))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(r, ", "))
+}
+
+// PySelf is the ctypes type of the float type.
+func (f *funcDef) PySelf() string {
+	if f.is32() {
+		return "c_float"
+	}
+	return "c_double"
+}
+
+// PyArgTypes is the argtypes list of the routine.
+func (f *funcDef) PyArgTypes() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := getPyParamType(p.typeName, f.PySelf())
+		r = append(r, t)
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(r, ", "))
+}
+
+// PyRestype is the restype of the routine.
+func (f *funcDef) PyRestype() string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.pyType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return "None"
+	case "float", "double":
+		return f.PySelf()
+	}
+	if isEnum(f.ReturnType) {
+		return "c_int"
+	}
+	if isStruct(f.ReturnType) {
+		return structTag(f.ReturnType)
+	}
+
+	return "c_void_p"
+}
+
+// PyCallParams are the arguments forwarded to the ctypes function, with arrays converted to pointers.
+func (f *funcDef) PyCallParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, isSelf := getPyParamType(p.typeName, f.PySelf())
+		switch {
+		case isSelf && strings.HasPrefix(t, "POINTER("):
+			r = append(r, fmt.Sprintf("_as_ptr(%s, %s)", p.name, f.PySelf()))
+		case t == "c_char", t == "c_char_p":
+			r = append(r, fmt.Sprintf("_as_char(%s)", p.name))
+		default:
+			r = append(r, p.name)
+		}
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// PyDispatchArg is the parameter whose dtype decides which precision is called.
+// When the routine takes no floats, the float64 one is always called.
+func (f *GoFuncPair) PyDispatchArg() string {
+	arg, _ := f.dispatchArg()
+	if arg == nil {
+		return "None"
+	}
+
+	return arg.name
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// importPython runs the module, with ctypes.CDLL loading a library of any routine and numpy replaced, to find the names it does not import.
+const importPython = `import ctypes, runpy, sys, types
+sys.modules["numpy"] = types.ModuleType("numpy")
+
+class Library:
+    def __getattr__(self, name):
+        routine = types.SimpleNamespace()
+        setattr(self, name, routine)
+        return routine
+
+ctypes.CDLL = lambda *args, **kwargs: Library()
+runpy.run_path(sys.argv[1])
+`
+
+func TestPythonImports(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not in PATH")
+	}
+
+	dir, files := generateFromHeader(t, serviceHeader, serviceList, &forPython, "mkl.py")
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if out, err := exec.Command(python, "-c", importPython, filepath.Join(dir, "mkl.py")).CombinedOutput(); err != nil {
+		t.Fatalf("the generated module fails to import: %v\n%s", err, out)
+	}
+}
+
+// the complex routines of mkl.h, returning and taking the complex types by value
+const complexValueHeader = `
+typedef struct { float real; float imag; } MKL_Complex8;
+typedef struct { double real; double imag; } MKL_Complex16;
+MKL_Complex16 cblas_zdotu(const int N, const MKL_Complex16 *X, const int incX, const MKL_Complex16 *Y, const int incY);
+MKL_Complex8 cblas_cdotu(const int N, const MKL_Complex8 *X, const int incX, const MKL_Complex8 *Y, const int incY);
+void zaxpy_val(const int N, const MKL_Complex16 alpha, MKL_Complex16 *X);
+`
+
+// the library of the routines, loaded by the module as libmkl_rt.so
+const complexValueLibrary = `#include "mkl.h"
+MKL_Complex16 cblas_zdotu(const int N, const MKL_Complex16 *X, const int incX, const MKL_Complex16 *Y, const int incY) {
+    MKL_Complex16 r = {0, 0};
+    for (int i = 0; i < N; i++) {
+        r.real += X[i * incX].real * Y[i * incY].real - X[i * incX].imag * Y[i * incY].imag;
+        r.imag += X[i * incX].real * Y[i * incY].imag + X[i * incX].imag * Y[i * incY].real;
+    }
+    return r;
+}
+MKL_Complex8 cblas_cdotu(const int N, const MKL_Complex8 *X, const int incX, const MKL_Complex8 *Y, const int incY) {
+    MKL_Complex8 r = {0, 0};
+    for (int i = 0; i < N; i++) {
+        r.real += X[i * incX].real * Y[i * incY].real - X[i * incX].imag * Y[i * incY].imag;
+        r.imag += X[i * incX].real * Y[i * incY].imag + X[i * incX].imag * Y[i * incY].real;
+    }
+    return r;
+}
+void zaxpy_val(const int N, const MKL_Complex16 alpha, MKL_Complex16 *X) {
+    for (int i = 0; i < N; i++) {
+        X[i].real += alpha.real;
+        X[i].imag += alpha.imag;
+    }
+}
+`
+
+const callComplexValue = `import runpy, sys, types
+sys.modules["numpy"] = types.ModuleType("numpy")
+sys.modules["numpy"].ndarray = type(None)
+mkl = runpy.run_path(sys.argv[1])
+
+x = (mkl["MKL_Complex16"] * 2)(mkl["MKL_Complex16"](1, 2), mkl["MKL_Complex16"](3, 4))
+mkl["zaxpy_val"](2, mkl["MKL_Complex16"](1, 1), x)
+dot = mkl["cblas_zdotu"](2, x, 1, x, 1)
+assert (dot.real, dot.imag) == (-14.0, 52.0), (dot.real, dot.imag)
+
+y = (mkl["MKL_Complex8"] * 1)(mkl["MKL_Complex8"](0.5, 1.5))
+dot = mkl["cblas_cdotu"](1, y, 1, y, 1)
+assert (dot.real, dot.imag) == (-2.0, 1.5), (dot.real, dot.imag)
+`
+
+func TestPythonComplex(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not in PATH")
+	}
+	compiler, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("cc is not in PATH")
+	}
+
+	dir, files := generateFromHeader(t, complexValueHeader, "cblas_%dotu\nzaxpy_val\n", &forPython, "mkl.py")
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mkl.c"), []byte(complexValueLibrary), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	build := exec.Command(compiler, "-shared", "-fPIC", "-o", "libmkl_rt.so", "mkl.c")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("the library fails to build: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command(python, "-c", callComplexValue, filepath.Join(dir, "mkl.py"))
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("the complex routines fail: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed r.tmpl
+var rTmplText string
+
+var (
+	forR         = false
+	rPackageName = "mklroutines"
+	rShimFile    = ""
+)
+
+func (*tmplInput) RPackageName() string {
+	return rPackageName
+}
+
+// getRShimPath is where the c shim is written. By default it is next to the r output.
+func getRShimPath(output string) string {
+	if rShimFile != "" {
+		return rShimFile
+	}
+
+	return strings.TrimSuffix(output, ".R") + ".c"
+}
+
+// rShimArg converts the SEXP called name to c type t, with self as the c float type.
+// float arrays are stored by the float package in integer vectors.
+// Types that are not known are passed as external pointers.
+func rShimArg(name string, t string, self string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return fmt.Sprintf(integer.rArg, name)
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]",
+		"double *", "float *", "float[]", "double[]":
+		if self == "float" {
+			return fmt.Sprintf("(float *)INTEGER(%s)", name)
+		}
+		return fmt.Sprintf("REAL(%s)", name)
+	case "double", "float", "const double", "const float":
+		return fmt.Sprintf("(%s)Rf_asReal(%s)", self, name)
+	case "char", "const char":
+		return fmt.Sprintf("CHAR(Rf_asChar(%s))[0]", name)
+	case "const char *":
+		return fmt.Sprintf("CHAR(Rf_asChar(%s))", name)
+	case "int *", "const int *":
+		return fmt.Sprintf("INTEGER(%s)", name)
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return fmt.Sprintf("(%s)Rf_asInteger(%s)", strings.TrimPrefix(t, "const "), name)
+	}
+	if isEnum(t) {
+		return fmt.Sprintf("(%s)Rf_asInteger(%s)", cEnumName(strings.TrimPrefix(t, "const ")), name)
+	}
+	if isStruct(t) {
+		// structs are passed as lists of their fields, in the order they are declared.
+		tag := structTag(t)
+		r := []string{}
+		for i, field := range structNames[tag].fields {
+			r = append(r, rShimArg(fmt.Sprintf("VECTOR_ELT(%s, %d)", name, i), field.typeName, fieldSelf(field, "double", "float")))
+		}
+		return fmt.Sprintf("(struct %s){%s}", tag, strings.Join(r, ", "))
+	}
+	// the pointers to the complex types are converted implicitly, as they are declared as MKL_Complex16 * by mkl and double _Complex * by openblas
+	if _, isComplex := complexFields[cBaseType(t)]; isComplex && t != cBaseType(t) {
+		return fmt.Sprintf("R_ExternalPtrAddr(%s)", name)
+	}
+
+	return fmt.Sprintf("(%s)R_ExternalPtrAddr(%s)", strings.TrimPrefix(t, "const "), name)
+}
+
+// rScalar is the SEXP of the value of c type t, such as the value returned or a field of the struct returned.
+func rScalar(t string, value string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return fmt.Sprintf(integer.rResult, value)
+	}
+	if base := strings.TrimPrefix(t, "const "); base == "float" || base == "double" {
+		return fmt.Sprintf("Rf_ScalarReal((double)%s)", value)
+	}
+	if isEnum(t) {
+		return fmt.Sprintf("Rf_ScalarInteger((int)%s)", value)
+	}
+
+	return fmt.Sprintf("R_MakeExternalPtr((void *)%s, R_NilValue, R_NilValue)", value)
+}
+
+// RSelf is the c type of the float type.
+func (f *funcDef) RSelf() string {
+	if f.is32() {
+		return "float"
+	}
+	return "double"
+}
+
+// RShimParams are the SEXP parameters of the shim.
+func (f *funcDef) RShimParams() string {
+	if len(f.args) == 0 {
+		return "void"
+	}
+
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, "SEXP "+p.name)
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// RNumArgs is the number of arguments registered for the shim.
+func (f *funcDef) RNumArgs() int {
+	return len(f.args)
+}
+
+// RShimBody calls the routine with the converted arguments and wraps its result in a SEXP.
+// The streams created by vslNewStream and the descriptors created by DftiCreateDescriptor are written back to the external pointers passed in,
+// such as new("externalptr").
+func (f *funcDef) RShimBody() string {
+	r := []string{}
+	before, after := []string{}, []string{}
+	for _, p := range f.args {
+		if p.typeName == "VSLStreamStatePtr *" || p.typeName == "DFTI_DESCRIPTOR_HANDLE *" {
+			handle := p.name + "_handle"
+			before = append(before, fmt.Sprintf("%s %s = R_ExternalPtrAddr(%s);", strings.TrimSuffix(p.typeName, " *"), handle, p.name))
+			r = append(r, "&"+handle)
+			after = append(after, fmt.Sprintf("R_SetExternalPtrAddr(%s, %s);", p.name, handle))
+			continue
+		}
+		// the complex types are lists of the real and imaginary parts, laid out as the type declared, MKL_Complex16 of mkl or double _Complex of openblas
+		if part, isComplex := complexFields[structTag(p.typeName)]; isComplex {
+			parts := fmt.Sprintf("(%s[]){%s, %s}", part, rShimArg(fmt.Sprintf("VECTOR_ELT(%s, 0)", p.name), part, part), rShimArg(fmt.Sprintf("VECTOR_ELT(%s, 1)", p.name), part, part))
+			r = append(r, fmt.Sprintf("*(%s *)%s", strings.TrimPrefix(p.declType, "const "), parts))
+			continue
+		}
+		r = append(r, rShimArg(p.name, p.typeName, f.RSelf()))
+	}
+	call := fmt.Sprintf("%s(%s)", f.RawName, strings.Join(r, ", "))
+
+	if isStruct(f.ReturnType) {
+		// structs are returned as lists of their fields, as they are passed
+		tag := structTag(f.ReturnType)
+		fields := structNames[tag].fields
+		before = append(before, fmt.Sprintf("%s value = %s;", strings.TrimPrefix(f.declReturnType, "const "), call), fmt.Sprintf("SEXP result = PROTECT(Rf_allocVector(VECSXP, %d));", len(fields)))
+		for i, field := range fields {
+			value := "value." + field.name
+			if part, isComplex := complexFields[tag]; isComplex {
+				value = fmt.Sprintf("((%s *)&value)[%d]", part, i)
+			}
+			before = append(before, fmt.Sprintf("SET_VECTOR_ELT(result, %d, %s);", i, rScalar(field.typeName, value)))
+		}
+		before = append(before, "UNPROTECT(1);")
+		after = append(after, "return result;")
+		return strings.Join(append(before, after...), "\n    ")
+	}
+
+	result := ""
+	if f.ReturnType != "void" {
+		result = rScalar(f.ReturnType, call)
+	}
+
+	switch {
+	case result == "":
+		before = append(before, call+";")
+		after = append(after, "return R_NilValue;")
+	case len(after) == 0:
+		before = append(before, fmt.Sprintf("return %s;", result))
+	default:
+		before = append(before, fmt.Sprintf("SEXP result = %s;", result))
+		after = append(after, "return result;")
+	}
+
+	return strings.Join(append(before, after...), "\n    ")
+}
+
+// RCallArgs are the arguments passed to .Call. For float32, arrays are passed as their integer storage and scalars as doubles.
+func (f *funcDef) RCallArgs() string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := getRustParamType(p.typeName)
+		switch {
+		case f.is32() && (t == "*const Self" || t == "*mut Self"):
+			r = append(r, p.name+"@Data")
+		case f.is32() && t == "Self":
+			r = append(r, fmt.Sprintf("as.double(%s)", p.name))
+		default:
+			r = append(r, p.name)
+		}
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// RDispatchArg is the parameter checked for the float32 class to decide which precision is called.
+// When the routine takes no floats, the float64 one is always called.
+func (f *GoFuncPair) RDispatchArg() string {
+	arg, _ := f.dispatchArg()
+	if arg == nil {
+		return ""
+	}
+
+	return arg.name
+}
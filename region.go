@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+const (
+	// regionBegin and regionEnd mark the lines of the output replaced with --in-place, in the comments of its language,
+	// such as // gen-mkl-wrapper:begin or # gen-mkl-wrapper:begin.
+	regionBegin = "gen-mkl-wrapper:begin"
+	regionEnd   = "gen-mkl-wrapper:end"
+)
+
+// inPlace replaces only the lines between the markers of the output, keeping the hand-written code around them.
+var inPlace = false
+
+// spliceRegion is the file at path with the lines between its markers replaced by generated.
+// The markers are kept, and the file must have exactly one of each, begin before end.
+func spliceRegion(path string, generated []byte) []byte {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		failf(exitInput, "--in-place replaces the region of %s, which does not exist", path)
+	}
+	orFail(exitInput, err)
+
+	lines := bytes.SplitAfter(existing, []byte("\n"))
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch {
+		case bytes.Contains(line, []byte(regionBegin)):
+			if begin >= 0 {
+				failf(exitInput, "%s has more than one %s marker, on lines %d and %d", path, regionBegin, begin+1, i+1)
+			}
+			begin = i
+		case bytes.Contains(line, []byte(regionEnd)):
+			if end >= 0 {
+				failf(exitInput, "%s has more than one %s marker, on lines %d and %d", path, regionEnd, end+1, i+1)
+			}
+			end = i
+		}
+	}
+	switch {
+	case begin < 0 || end < 0:
+		failf(exitInput, "%s has no region between %s and %s markers to replace", path, regionBegin, regionEnd)
+	case end < begin:
+		failf(exitInput, "%s has the %s marker on line %d before the %s marker on line %d", path, regionEnd, end+1, regionBegin, begin+1)
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.Join(lines[:begin+1], nil))
+	// the begin marker may be the last line, without a newline
+	if !bytes.HasSuffix(lines[begin], []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	b.Write(generated)
+	if len(generated) > 0 && !bytes.HasSuffix(generated, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	b.Write(bytes.Join(lines[end:], nil))
+
+	return b.Bytes()
+}
+
+// InPlace is whether the output goes between the markers of a file, which leaves out what must be at its top,
+// such as the #![allow] attributes of rust.
+func (i *tmplInput) InPlace() bool {
+	return inPlace
+}
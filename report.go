@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+var (
+	// strict fails on the entries of the function list selecting none or only some of their routines in the header,
+	// which would otherwise only be found missing when the generated code is used.
+	strict = false
+	// reportPath is the file of --report, which the entries matching nothing and the unmapped types are written to.
+	reportPath = ""
+)
+
+// unmatchedEntry is an entry of the function list selecting none or only some of its routines in the header.
+type unmatchedEntry struct {
+	line string
+	// found and missing are the routines of the entry that the header declares and does not.
+	found   []string
+	missing []string
+}
+
+func (u unmatchedEntry) String() string {
+	if len(u.found) == 0 {
+		return fmt.Sprintf("%s matches nothing in the header", u.line)
+	}
+
+	return fmt.Sprintf("%s matches %s but not %s", u.line, strings.Join(u.found, ", "), strings.Join(u.missing, ", "))
+}
+
+// unmatchedEntries are the entries selecting none or only some of their routines, such as cblas_*axpby
+// when the header only declares cblas_daxpby, and the names without wildcards that are neither routines nor constants.
+func (f *funcListInput) unmatchedEntries(ast *cc.AST, declared map[string]struct{}) []unmatchedEntry {
+	found := make(map[string][]string)
+	missing := make(map[string][]string)
+	for name := range f.names {
+		line := f.lineOf(name)
+		_, isDeclared := declared[name]
+		if _, isPlain := f.plainNames[name]; isPlain && !isDeclared {
+			_, isDeclared = constantValue(ast, name)
+		}
+		if isDeclared {
+			found[line] = append(found[line], name)
+		} else {
+			missing[line] = append(missing[line], name)
+		}
+	}
+
+	r := make([]unmatchedEntry, 0, len(missing))
+	for line, names := range missing {
+		slices.Sort(names)
+		slices.Sort(found[line])
+		r = append(r, unmatchedEntry{line: line, found: found[line], missing: names})
+	}
+	slices.SortFunc(r, func(a, b unmatchedEntry) int { return strings.Compare(a.line, b.line) })
+
+	return r
+}
+
+// checkUnmatched warns about the entries selecting none or only some of their routines, or fails with --strict.
+func checkUnmatched(unmatched []unmatchedEntry) {
+	if strict && len(unmatched) > 0 {
+		entries := make([]string, 0, len(unmatched))
+		for _, u := range unmatched {
+			entries = append(entries, u.String())
+		}
+		failf(exitInput, "the entries of the function list are not all declared in the header: %s", strings.Join(entries, "; "))
+	}
+
+	for _, u := range unmatched {
+		warnf("%s", u)
+	}
+}
+
+// writeReport writes to path the entries of the function list matching none or only some of their routines,
+// and the routines whose return types have no rust or go type, for adding a family of routines to the list.
+func writeReport(path string, unmatched []unmatchedEntry, funcs []funcDef) {
+	var b bytes.Buffer
+
+	fmt.Fprintln(&b, "entries matching nothing in the header:")
+	for _, u := range unmatched {
+		if len(u.found) == 0 {
+			fmt.Fprintf(&b, "  %s\n", u.line)
+		}
+	}
+
+	fmt.Fprintln(&b, "entries matching only some of their routines:")
+	for _, u := range unmatched {
+		if len(u.found) > 0 {
+			fmt.Fprintf(&b, "  %s: found %s, missing %s\n", u.line, strings.Join(u.found, ", "), strings.Join(u.missing, ", "))
+		}
+	}
+
+	// the parameters of the types without a built-in one are taken as they are named in the header,
+	// but the returns fail unless --allow-raw-returns
+	fmt.Fprintln(&b, "routines returning types without a rust or go type:")
+	for i := range funcs {
+		f := &funcs[i]
+		langs := []string{}
+		if _, mapped := f.rustReturnType(); !mapped {
+			langs = append(langs, "rust")
+		}
+		if _, mapped := f.goReturnType(); !mapped {
+			langs = append(langs, "go")
+		}
+		if len(langs) > 0 {
+			fmt.Fprintf(&b, "  %s returns %s, which has no %s type\n", f.RawName, f.ReturnType, strings.Join(langs, " or "))
+		}
+	}
+
+	orFail(exitOutput, os.WriteFile(path, b.Bytes(), 0o666))
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// complexTraitName is the trait of the complex routines. They are not in the same trait as the real routines,
+	// since cblas takes the complex scalars by pointer while the real scalars are taken by value.
+	complexTraitName = "MKLComplexRoutines"
+	// rustComplexType is the generic complex type the complex routines are implemented for, it must be laid out as MKL_Complex8/MKL_Complex16.
+	rustComplexType = "num_complex::Complex"
+	// f16TraitName and bf16TraitName are the traits of the half precision routines, implemented for the types of the half crate.
+	f16TraitName  = "MKLF16Routines"
+	bf16TraitName = "MKLBF16Routines"
+	// mixedF32TraitName and mixedI32TraitName are the traits of the mixed precision routines, by the output type.
+	// Self is the input type, so each implementation is for a pair of input and output types.
+	mixedF32TraitName = "MKLMixedF32Routines"
+	mixedI32TraitName = "MKLMixedI32Routines"
+	// stridedBatchHelpers adds to the trait a _packed version of cblas_?gemm_batch_strided, which derives the strides from the dimensions.
+	stridedBatchHelpers = false
+	// rustFixedArrays makes the array parameters with an extent, such as const float a[4], pointers to rust arrays.
+	rustFixedArrays = false
+)
+
+// packedStrides are the strides of cblas_?gemm_batch_strided when the matrices are packed one after another,
+// which is the leading dimension times the outer dimension of the matrix. outer is defined in rs.tmpl.
+var packedStrides = map[string]string{
+	"stridea": "lda * outer(TransA as i32, M, K)",
+	"strideb": "ldb * outer(TransB as i32, K, N)",
+	"stridec": "ldc * outer(111, M, N)",
+}
+
+// HasPackedStrides is true if the _packed version of the routine is generated, which needs the parameters of cblas_?gemm_batch_strided.
+func (f *funcDef) HasPackedStrides() bool {
+	if !stridedBatchHelpers || !f.isReal() {
+		return false
+	}
+
+	names := make(map[string]struct{})
+	for _, p := range f.args {
+		names[p.name] = struct{}{}
+	}
+	for _, name := range []string{"Layout", "TransA", "TransB", "M", "N", "K", "lda", "ldb", "ldc", "stridea", "strideb", "stridec"} {
+		if _, ok := names[name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PackedStridesParams are the parameters of the _packed version of the routine, which are those without the strides.
+func (f *funcDef) PackedStridesParams() []string {
+	r := []string{}
+	for i, p := range f.args {
+		if _, isStride := packedStrides[p.name]; !isStride {
+			r = append(r, f.Params()[i])
+		}
+	}
+
+	return r
+}
+
+// PackedStridesCallArgs are the arguments to the routine from its _packed version.
+func (f *funcDef) PackedStridesCallArgs() []string {
+	r := []string{}
+	for _, p := range f.args {
+		if stride, isStride := packedStrides[p.name]; isStride {
+			r = append(r, stride)
+		} else {
+			r = append(r, rustParamName(p.name))
+		}
+	}
+
+	return r
+}
+
+// rustKeywords are the rust keywords, which the parameters of the routines are not named, such as in of fftw_plan_dft_1d.
+var rustKeywords = map[string]struct{}{
+	"as": {}, "async": {}, "await": {}, "break": {}, "const": {}, "continue": {}, "crate": {}, "dyn": {}, "else": {},
+	"enum": {}, "extern": {}, "false": {}, "fn": {}, "for": {}, "if": {}, "impl": {}, "in": {}, "let": {}, "loop": {},
+	"match": {}, "mod": {}, "move": {}, "mut": {}, "pub": {}, "ref": {}, "return": {}, "self": {}, "static": {},
+	"struct": {}, "super": {}, "trait": {}, "true": {}, "type": {}, "unsafe": {}, "use": {}, "where": {}, "while": {},
+	"abstract": {}, "become": {}, "box": {}, "do": {}, "final": {}, "gen": {}, "macro": {}, "override": {}, "priv": {},
+	"try": {}, "typeof": {}, "unsized": {}, "virtual": {}, "yield": {},
+}
+
+// rustParamName is the c parameter name with _ appended if it is a rust keyword, as r#self and the like are not allowed.
+func rustParamName(name string) string {
+	if _, isKeyword := rustKeywords[name]; isKeyword {
+		return name + "_"
+	}
+
+	return name
+}
+
+// rustType is the rust type of the function pointer, which can be null.
+func (f *funcPointer) rustType() string {
+	args := []string{}
+	for _, arg := range f.args {
+		t, _ := getRustParamType(arg.typeName)
+		args = append(args, t)
+	}
+	ret := ""
+	if f.returnType != "void" {
+		t, _ := getRustParamType(f.returnType)
+		ret = " -> " + t
+	}
+
+	return fmt.Sprintf("Option<unsafe extern \"C\" fn(%s)%s>", strings.Join(args, ", "), ret)
+}
+
+// isComplex is true for routines on MKL_Complex8 or MKL_Complex16.
+func (f *funcDef) isComplex() bool {
+	return f.elem == complex64Elem || f.elem == complex128Elem
+}
+
+// isReal is true for routines on float or double.
+func (f *funcDef) isReal() bool {
+	return f.elem == float64Elem || f.elem == float32Elem
+}
+
+// rustParamType is the rust type of c type t in the routine.
+// For the real routines both float and double are Self, and the complex types are the complex type of Self. For the others only the element type of the routine is Self,
+// and cblas passes the complex numbers as void pointers. cblas_gemm_s8u8s32 takes both of its int8 and uint8 matrices
+// as void pointers, and they are both Self.
+func (f *funcDef) rustParamType(t string) (string, bool) {
+	// the types of the precision, such as fftw_plan, are the associated types of the traits
+	if name, isPrecision := precisionTypedef(t); isPrecision && f.elem != noElem {
+		return "Self::" + name, true
+	}
+	if base := cBaseType(t); f.isReal() && base != "MKL_Complex8" && base != "MKL_Complex16" {
+		return getRustParamType(t)
+	}
+
+	// array parameters are pointers, such as *const Self for const MKL_Complex8 A[]
+	if elem, isArray := strings.CutSuffix(t, "[]"); isArray {
+		t = elem + " *"
+	}
+
+	// pointer to pointer, such as the arrays of the batch routines
+	if pointee, isConst, isPointer := cutPointer(t); isPointer && strings.HasSuffix(pointee, "*") {
+		r, known := f.rustParamType(pointee)
+		if isConst {
+			return "*const " + r, known
+		}
+		return "*mut " + r, known
+	}
+
+	base, isPointer := strings.CutSuffix(t, " *")
+	isConst := strings.HasPrefix(base, "const ")
+	base = strings.TrimPrefix(base, "const ")
+
+	self := ""
+	switch {
+	// the real routines taking complex arrays, such as fftw_plan_dft_r2c_1d, take them as the complex type of Self
+	case f.isReal():
+		self = rustComplexType + "<Self>"
+	case base == f.elem.cType(), (f.isComplex() || f.elem == int8Elem) && base == "void" && isPointer:
+		self = "Self"
+	case base == "float":
+		self = "f32"
+	case base == "double":
+		self = "f64"
+	case base == "MKL_F16", base == "MKL_BF16":
+		self = "u16"
+	case base == "MKL_INT8":
+		self = "i8"
+	case base == "MKL_INT16":
+		self = "i16"
+	case base == "MKL_INT32":
+		self = "i32"
+	default:
+		return getRustParamType(t)
+	}
+
+	switch {
+	case !isPointer:
+		return self, true
+	case isConst:
+		return "*const " + self, true
+	default:
+		return "*mut " + self, true
+	}
+}
+
+// SelfParams are the parameters of a routine other than the real ones.
+func (f *funcDef) SelfParams() []string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := f.rustParamType(p.typeName)
+		if vsl := f.vslParamType(p); vsl != "" {
+			t = vsl
+		}
+		r = append(r, fmt.Sprintf("%s: %s", rustParamName(p.name), rustFixedArrayType(t, p)))
+	}
+
+	return r
+}
+
+// rustFixedArrayType is the rust pointer type t of parameter p as a pointer to a rust array,
+// such as *const [Self; 4] for const float a[4], when p has an extent and --rust-fixed-arrays is set.
+func rustFixedArrayType(t string, p funcArg) string {
+	if !rustFixedArrays || p.extent == "" {
+		return t
+	}
+	for _, pointer := range []string{"*const ", "*mut "} {
+		if elem, isPointer := strings.CutPrefix(t, pointer); isPointer {
+			return fmt.Sprintf("%s[%s; %s]", pointer, elem, p.extent)
+		}
+	}
+
+	return t
+}
+
+// SizedBound is the where clause of a trait function taking rust arrays of Self or the complex type of Self, which need Self to be sized.
+func (f *funcDef) SizedBound() string {
+	for _, p := range f.args {
+		if t, _ := f.rustParamType(p.typeName); rustFixedArrays && p.extent != "" || strings.HasSuffix(t, "<Self>") {
+			return " where Self: Sized"
+		}
+	}
+
+	return ""
+}
+
+// RustCallParams are the arguments to a real routine, the pointers to rust arrays are cast back to pointers to their elements,
+// and the enums and the VSL constants defined in the rust output are cast to the types of the provider crate.
+func (f *funcDef) RustCallParams() []string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := f.rustParamType(p.typeName)
+		name := rustParamName(p.name)
+		switch {
+		case rustFixedArrayType(t, p) != t:
+			r = append(r, fmt.Sprintf("%s.cast()", name))
+		case f.vslParamType(p) != "":
+			r = append(r, fmt.Sprintf("%s.0 as _", name))
+		case isRustEnum(t), strings.HasSuffix(t, "<Self>"):
+			r = append(r, fmt.Sprintf("%s as _", name))
+		default:
+			r = append(r, name)
+		}
+	}
+
+	return r
+}
+
+// SelfCallParams are the arguments to a routine other than the real ones, converted from the rust types to the MKL types.
+func (f *funcDef) SelfCallParams() []string {
+	r := []string{}
+	for _, p := range f.args {
+		// the pointers to Self include the arrays of pointers of the batch routines, such as *mut *const Self
+		name := rustParamName(p.name)
+		switch t, _ := f.rustParamType(p.typeName); {
+		case f.vslParamType(p) != "":
+			r = append(r, fmt.Sprintf("%s.0 as _", name))
+		case strings.HasPrefix(t, "*") && strings.HasSuffix(t, " Self"):
+			r = append(r, fmt.Sprintf("%s as _", name))
+		case t == "Self":
+			r = append(r, fmt.Sprintf("std::mem::transmute(%s)", name))
+		case isRustEnum(t):
+			r = append(r, fmt.Sprintf("%s as _", name))
+		default:
+			r = append(r, name)
+		}
+	}
+
+	return r
+}
+
+// ReturnsSelf is true when the routine returns the element type.
+func (f *funcDef) ReturnsSelf() bool {
+	return f.ReturnDeclare() == "-> Self"
+}
+
+// rustImpl is the implementation of a trait for a rust type.
+type rustImpl struct {
+	Type  string
+	Funcs []*funcDef
+}
+
+// rustTrait is a trait of the routines other than the real ones, which are in their own traits since their signatures differ.
+// The trait functions are those of the first implementation.
+type rustTrait struct {
+	TraitName  string
+	TraitFuncs []*funcDef
+	Impls      []*rustImpl
+}
+
+// assocType is an associated type of a rust trait, which is Type in an implementation.
+type assocType struct {
+	Name string
+	Type string
+}
+
+// assocTypes are the types of precisionTypedefs the routines take or return, which are the associated types of their traits,
+// such as fftw_plan, which is fftwf_plan in the implementation for f32.
+func assocTypes(funcs []*funcDef) []assocType {
+	r := []assocType{}
+	for _, f := range funcs {
+		types := []string{f.ReturnType}
+		for _, p := range f.args {
+			types = append(types, p.typeName)
+		}
+		for _, t := range types {
+			name, isPrecision := precisionTypedef(t)
+			if !isPrecision || slices.ContainsFunc(r, func(a assocType) bool { return a.Name == name }) {
+				continue
+			}
+			r = append(r, assocType{Name: name, Type: strings.TrimPrefix(t, "const ")})
+		}
+	}
+	slices.SortFunc(r, func(a, b assocType) int { return strings.Compare(a.Name, b.Name) })
+
+	return r
+}
+
+// AssocTypes are the associated types of the trait of the real routines.
+func (i *tmplInput) AssocTypes() []assocType {
+	return assocTypes(i.TraitFuncs())
+}
+
+// F64AssocTypes and F32AssocTypes are the associated types of the implementations of the trait of the real routines.
+func (i *tmplInput) F64AssocTypes() []assocType {
+	return assocTypes(i.F64Funcs())
+}
+
+func (i *tmplInput) F32AssocTypes() []assocType {
+	return assocTypes(i.F32Funcs())
+}
+
+// AssocTypes are the associated types of the trait.
+func (t *rustTrait) AssocTypes() []assocType {
+	return assocTypes(t.TraitFuncs)
+}
+
+// AssocTypes are the associated types of the implementation.
+func (m *rustImpl) AssocTypes() []assocType {
+	return assocTypes(m.Funcs)
+}
+
+// RustTraits are the traits of the complex and half precision routines that are selected.
+func (i *tmplInput) RustTraits() []*rustTrait {
+	r := []*rustTrait{}
+	if c64funcs := i.C64Funcs(); len(c64funcs) > 0 {
+		r = append(r, &rustTrait{
+			TraitName:  complexTraitName,
+			TraitFuncs: c64funcs,
+			Impls: []*rustImpl{
+				{Type: rustComplexType + "<f64>", Funcs: i.C128Funcs()},
+				{Type: rustComplexType + "<f32>", Funcs: c64funcs},
+			},
+		})
+	}
+	if f16funcs := i.F16Funcs(); len(f16funcs) > 0 {
+		r = append(r, &rustTrait{
+			TraitName:  f16TraitName,
+			TraitFuncs: f16funcs,
+			Impls:      []*rustImpl{{Type: "half::f16", Funcs: f16funcs}},
+		})
+	}
+	if bf16funcs := i.BF16Funcs(); len(bf16funcs) > 0 {
+		r = append(r, &rustTrait{
+			TraitName:  bf16TraitName,
+			TraitFuncs: bf16funcs,
+			Impls:      []*rustImpl{{Type: "half::bf16", Funcs: bf16funcs}},
+		})
+	}
+	if t := mixedRustTrait(mixedF32TraitName, []*rustImpl{
+		{Type: "half::bf16", Funcs: i.MixedFuncs(bfloat16Elem, float32Elem)},
+		{Type: "half::f16", Funcs: i.MixedFuncs(float16Elem, float32Elem)},
+	}); t != nil {
+		r = append(r, t)
+	}
+	if t := mixedRustTrait(mixedI32TraitName, []*rustImpl{
+		{Type: "i16", Funcs: i.MixedFuncs(int16Elem, int32Elem)},
+		{Type: "i8", Funcs: i.MixedFuncs(int8Elem, int32Elem)},
+	}); t != nil {
+		r = append(r, t)
+	}
+
+	return r
+}
+
+// mixedRustTrait is the trait of the mixed precision routines with the same output type, implemented for the input types
+// that have routines selected. It is nil if there is none.
+func mixedRustTrait(traitName string, impls []*rustImpl) *rustTrait {
+	t := &rustTrait{TraitName: traitName}
+	for _, impl := range impls {
+		if len(impl.Funcs) == 0 {
+			continue
+		}
+		if t.TraitFuncs == nil {
+			t.TraitFuncs = impl.Funcs
+		}
+		t.Impls = append(t.Impls, impl)
+	}
+	if len(t.Impls) == 0 {
+		return nil
+	}
+
+	return t
+}
+
+// splitUnsafeOnly moves the routines of the entries with unsafe-only out of the routines of the output and of its groups,
+// which rust re-exports from the provider crate as they are declared, without the safe wrappers.
+func (i *tmplInput) splitUnsafeOnly() {
+	for _, input := range append([]*tmplInput{i}, i.groups...) {
+		wrapped := []funcDef{}
+		input.unsafeOnly = nil
+		for _, f := range input.funcDefs {
+			if f.unsafeOnly {
+				input.unsafeOnly = append(input.unsafeOnly, f)
+			} else {
+				wrapped = append(wrapped, f)
+			}
+		}
+		input.funcDefs = wrapped
+	}
+}
+
+// UnsafeOnlyFuncs are the routines of the group of the output with unsafe-only, re-exported from the provider crate.
+func (i *tmplInput) UnsafeOnlyFuncs() []funcDef {
+	r := []funcDef{}
+	for _, f := range i.unsafeOnly {
+		if f.group == i.group {
+			r = append(r, f)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the banded routines of cblas and lapacke declared with the array syntax, as the headers of netlib and openblas do
+const bandedHeader = `
+typedef enum {CblasRowMajor=101, CblasColMajor=102} CBLAS_LAYOUT;
+typedef enum {CblasNoTrans=111, CblasTrans=112, CblasConjTrans=113} CBLAS_TRANSPOSE;
+typedef enum {CblasUpper=121, CblasLower=122} CBLAS_UPLO;
+typedef enum {CblasNonUnit=131, CblasUnit=132} CBLAS_DIAG;
+typedef struct { double real; double imag; } MKL_Complex16;
+typedef struct { float real; float imag; } MKL_Complex8;
+void cblas_sgbmv(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const int M, const int N, const int KL, const int KU,
+	const float alpha, const float A[], const int lda, const float X[], const int incX, const float beta, float Y[], const int incY);
+void cblas_dgbmv(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const int M, const int N, const int KL, const int KU,
+	const double alpha, const double A[], const int lda, const double X[], const int incX, const double beta, double Y[], const int incY);
+void cblas_cgbmv(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const int M, const int N, const int KL, const int KU,
+	const void *alpha, const MKL_Complex8 A[], const int lda, const MKL_Complex8 X[], const int incX, const void *beta, MKL_Complex8 Y[], const int incY);
+void cblas_zgbmv(const CBLAS_LAYOUT Layout, const CBLAS_TRANSPOSE TransA, const int M, const int N, const int KL, const int KU,
+	const void *alpha, const MKL_Complex16 A[], const int lda, const MKL_Complex16 X[], const int incX, const void *beta, MKL_Complex16 Y[], const int incY);
+void cblas_stbsv(const CBLAS_LAYOUT Layout, const CBLAS_UPLO Uplo, const CBLAS_TRANSPOSE TransA, const CBLAS_DIAG Diag,
+	const int N, const int K, const float A[], const int lda, float X[], const int incX);
+void cblas_dtbsv(const CBLAS_LAYOUT Layout, const CBLAS_UPLO Uplo, const CBLAS_TRANSPOSE TransA, const CBLAS_DIAG Diag,
+	const int N, const int K, const double A[], const int lda, double X[], const int incX);
+int LAPACKE_sgbtrs(int matrix_layout, char trans, int n, int kl, int ku, int nrhs, const float ab[], int ldab, const int ipiv[], float b[], int ldb);
+int LAPACKE_dgbtrs(int matrix_layout, char trans, int n, int kl, int ku, int nrhs, const double ab[], int ldab, const int ipiv[], double b[], int ldb);
+`
+
+const bandedList = `cblas_*gbmv
+cblas_%gbmv
+cblas_*tbsv
+LAPACKE_*gbtrs
+`
+
+func TestRustArrayParams(t *testing.T) {
+	funcs := selectFromHeader(t, bandedHeader, bandedList)
+
+	tests := []struct {
+		routine string
+		params  map[string]string
+	}{
+		{"cblas_sgbmv", map[string]string{"A": "*const Self", "X": "*const Self", "Y": "*mut Self", "alpha": "Self"}},
+		{"cblas_dgbmv", map[string]string{"A": "*const Self", "X": "*const Self", "Y": "*mut Self", "beta": "Self"}},
+		{"cblas_cgbmv", map[string]string{"A": "*const Self", "X": "*const Self", "Y": "*mut Self", "alpha": "*const Self"}},
+		{"cblas_zgbmv", map[string]string{"A": "*const Self", "X": "*const Self", "Y": "*mut Self", "beta": "*const Self"}},
+		{"cblas_stbsv", map[string]string{"A": "*const Self", "X": "*mut Self"}},
+		{"cblas_dtbsv", map[string]string{"A": "*const Self", "X": "*mut Self"}},
+		{"LAPACKE_sgbtrs", map[string]string{"ab": "*const Self", "ipiv": "*const i32", "b": "*mut Self"}},
+		{"LAPACKE_dgbtrs", map[string]string{"ab": "*const Self", "ipiv": "*const i32", "b": "*mut Self"}},
+	}
+	for _, test := range tests {
+		f, found := funcs[test.routine]
+		if !found {
+			t.Fatalf("%s is not selected", test.routine)
+		}
+		checked := 0
+		for _, p := range f.args {
+			want, isChecked := test.params[p.name]
+			if !isChecked {
+				continue
+			}
+			checked++
+			if got, _ := f.rustParamType(p.typeName); got != want {
+				t.Errorf("%s of %s, declared as %s, is %q in rust, want %q", p.name, test.routine, p.declType, got, want)
+			}
+		}
+		if checked != len(test.params) {
+			t.Errorf("%s has %d of the parameters %v", test.routine, checked, test.params)
+		}
+	}
+}
+
+const unsafeOnlyHeader = `
+void cblas_dscal(const int N, const double alpha, double *X, const int incX);
+void cblas_sscal(const int N, const float alpha, float *X, const int incX);
+double cblas_ddot(const int N, const double *X, const int incX, const double *Y, const int incY);
+float cblas_sdot(const int N, const float *X, const int incX, const float *Y, const int incY);
+void mkl_free_buffers(void);
+`
+
+// the crate declaring the routines the output re-exports, and calling them through the output
+const unsafeOnlyCrate = `#![allow(non_snake_case)]
+extern "C" {
+    pub fn cblas_dscal(N: i32, alpha: f64, X: *mut f64, incX: i32);
+    pub fn cblas_sscal(N: i32, alpha: f32, X: *mut f32, incX: i32);
+    pub fn cblas_ddot(N: i32, X: *const f64, incX: i32, Y: *const f64, incY: i32) -> f64;
+    pub fn cblas_sdot(N: i32, X: *const f32, incX: i32, Y: *const f32, incY: i32) -> f32;
+    pub fn mkl_free_buffers();
+}
+
+pub mod mkl;
+
+pub fn scaled_norm(x: &mut [f64]) -> f64 {
+    use mkl::MKLRoutines;
+    unsafe { mkl::cblas_dscal(x.len() as i32, 2.0, x.as_mut_ptr(), 1) };
+    unsafe { mkl::service::mkl_free_buffers() };
+    f64::cblas_dot(x.len() as i32, x.as_ptr(), 1, x.as_ptr(), 1)
+}
+`
+
+func TestRustUnsafeOnly(t *testing.T) {
+	// rust is the output without the flag of another language
+	forRust := false
+	dir, files := generateFromHeader(t, unsafeOnlyHeader, "cblas_*scal unsafe-only\ncblas_*dot\nmkl_free_buffers unsafe-only group:service\n", &forRust, "mkl.rs")
+	output := string(files[0].content)
+	for _, want := range []string{"pub use crate::cblas_dscal;", "pub use crate::cblas_sscal;", "pub use crate::mkl_free_buffers;", "fn cblas_dot("} {
+		if !strings.Contains(output, want) {
+			t.Errorf("the output has no %s:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"fn cblas_scal(", "fn mkl_free_buffers("} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("the output has the safe wrapper %s of a routine with unsafe-only:\n%s", unwanted, output)
+		}
+	}
+
+	rustc, err := exec.LookPath("rustc")
+	if err != nil {
+		t.Skip("rustc is not in PATH")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mkl.rs"), files[0].content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib.rs"), []byte(unsafeOnlyCrate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(rustc, "--edition", "2021", "--crate-type", "lib", "--emit", "metadata", "--out-dir", dir, "lib.rs")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("the output fails to build: %v\n%s", err, out)
+	}
+}
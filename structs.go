@@ -0,0 +1,137 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// structNames are the structs the selected routines take by value, such as struct matrix_descr of the sparse routines, by their tags.
+var structNames = make(map[string]*structDef)
+
+// structDef is a struct the routines take by value, declared again in the outputs that don't read the header.
+type structDef struct {
+	// Name is the tag of the struct, such as matrix_descr.
+	Name   string
+	fields []funcArg
+}
+
+// complexFields are the fields of the complex types, which mkl.h declares as structs of the real and imaginary parts.
+var complexFields = map[string]string{"MKL_Complex8": "float", "MKL_Complex16": "double"}
+
+// structTag is the tag of the struct t passed by value, such as matrix_descr for const struct matrix_descr, or empty if t is not one.
+// The complex types passed by value, such as MKL_Complex16, are the structs of their names.
+func structTag(t string) string {
+	t = strings.TrimPrefix(t, "const ")
+	if _, isComplex := complexFields[t]; isComplex {
+		return t
+	}
+	if !strings.HasPrefix(t, "struct ") || strings.HasSuffix(t, "*") {
+		return ""
+	}
+
+	return strings.TrimPrefix(t, "struct ")
+}
+
+// fieldSelf is f32 for the fields of float, such as the parts of MKL_Complex8, and f64 for the others, the float types of the output.
+func fieldSelf(field funcArg, f64 string, f32 string) string {
+	if cBaseType(field.typeName) == "float" {
+		return f32
+	}
+
+	return f64
+}
+
+// isStruct is true if t is a struct the routines take by value.
+func isStruct(t string) bool {
+	_, found := structNames[structTag(t)]
+	return found
+}
+
+// isEnum is true if t is an enum the routines take or return, such as const sparse_operation_t, which is passed as an int.
+func isEnum(t string) bool {
+	t = strings.TrimPrefix(t, "const ")
+	_, taken := enumNames[t]
+	_, other := otherEnumNames[t]
+
+	return taken || other
+}
+
+// structType is the definition of the struct with the tag, or nil if the header doesn't define it.
+func structType(ast *cc.AST, tag string) *cc.StructType {
+	for tu := ast.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+		d := tu.ExternalDeclaration
+		if d == nil || d.Declaration == nil {
+			continue
+		}
+		for s := d.Declaration.DeclarationSpecifiers; s != nil; s = s.DeclarationSpecifiers {
+			if s.Case != cc.DeclarationSpecifiersTypeSpec || s.TypeSpecifier.Case != cc.TypeSpecifierStructOrUnion {
+				continue
+			}
+			spec := s.TypeSpecifier.StructOrUnionSpecifier
+			if spec.Case != cc.StructOrUnionSpecifierDef || spec.Token.SrcStr() != tag {
+				continue
+			}
+			if st, ok := spec.Type().(*cc.StructType); ok {
+				return st
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldType is the type name of a field, the typedef it is declared with, such as sparse_matrix_type_t, or the fundamental type.
+func fieldType(t cc.Type) string {
+	if d := t.Typedef(); d != nil {
+		return d.Name()
+	}
+	if fundamental, ok := fundamentalType(t); ok {
+		return fundamental
+	}
+
+	return t.String()
+}
+
+// retrieveStructs are the structs the routines take or return by value, sorted by tag, with the fields in the order they are declared.
+func retrieveStructs(ast *cc.AST, funcs []funcDef) []*structDef {
+	for _, f := range funcs {
+		types := []string{f.ReturnType}
+		for _, p := range f.args {
+			types = append(types, p.typeName)
+		}
+		for _, t := range types {
+			tag := structTag(t)
+			if _, done := structNames[tag]; tag == "" || done {
+				continue
+			}
+			if part, isComplex := complexFields[tag]; isComplex {
+				structNames[tag] = &structDef{Name: tag, fields: []funcArg{{name: "real", typeName: part}, {name: "imag", typeName: part}}}
+				continue
+			}
+			st := structType(ast, tag)
+			if st == nil {
+				continue
+			}
+			def := &structDef{Name: tag}
+			for i := 0; i < st.NumFields(); i++ {
+				field := st.FieldByIndex(i)
+				t := fieldType(field.Type())
+				if _, taken := enumNames[t]; !taken && enumType(ast, t) != nil {
+					otherEnumNames[t] = struct{}{}
+				}
+				def.fields = append(def.fields, funcArg{name: field.Name(), typeName: t})
+			}
+			structNames[tag] = def
+		}
+	}
+
+	r := make([]*structDef, 0, len(structNames))
+	for _, s := range structNames {
+		r = append(r, s)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name < r[j].Name })
+
+	return r
+}
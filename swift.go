@@ -0,0 +1,118 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed swift.tmpl
+var swiftTmplText string
+
+var (
+	forSwift            = false
+	swiftProtocolName   = "MKLScalar"
+	swiftBridgingHeader = ""
+)
+
+func (*tmplInput) SwiftProtocolName() string {
+	return swiftProtocolName
+}
+
+// getSwiftBridgingHeaderPath is where the bridging header is written. By default it is next to the swift output.
+func getSwiftBridgingHeaderPath(output string) string {
+	if swiftBridgingHeader != "" {
+		return swiftBridgingHeader
+	}
+
+	return strings.TrimSuffix(output, ".swift") + "-Bridging-Header.h"
+}
+
+// getSwiftParamType returns the swift type of c type t as imported by the bridging header.
+// Types that are not known are referred to by their c names, which are imported from the header.
+func getSwiftParamType(t string) string {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.swiftType
+	}
+
+	if fn, isFunc := funcPointerTypes[t]; isFunc {
+		args := []string{}
+		for _, arg := range fn.args {
+			args = append(args, getSwiftParamType(arg.typeName))
+		}
+		ret := "Void"
+		if fn.returnType != "void" {
+			ret = getSwiftParamType(fn.returnType)
+		}
+		return fmt.Sprintf("(@convention(c) (%s) -> %s)?", strings.Join(args, ", "), ret)
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return "UnsafePointer<Self>?"
+	case "double *", "float *", "float[]", "double[]":
+		return "UnsafeMutablePointer<Self>?"
+	case "double", "float", "const double", "const float":
+		return "Self"
+	case "char", "const char":
+		return "CChar"
+	case "int *":
+		return "UnsafeMutablePointer<Int32>?"
+	case "const int *":
+		return "UnsafePointer<Int32>?"
+	case "int64_t *":
+		return "UnsafeMutablePointer<Int64>?"
+	case "const int64_t *":
+		return "UnsafePointer<Int64>?"
+	case "void *":
+		return "UnsafeMutableRawPointer?"
+	case "const void *":
+		return "UnsafeRawPointer?"
+	case "sparse_matrix_t", "const sparse_matrix_t":
+		// the pointer to the incomplete struct sparse_matrix is imported as an optional OpaquePointer
+		return "sparse_matrix_t?"
+	}
+
+	// pointer to pointer, such as the arrays of the batch routines
+	if pointee, isConst, isPointer := cutPointer(t); isPointer {
+		if isConst {
+			return fmt.Sprintf("UnsafePointer<%s>?", getSwiftParamType(pointee))
+		}
+		return fmt.Sprintf("UnsafeMutablePointer<%s>?", getSwiftParamType(pointee))
+	}
+
+	// struct matrix_descr is imported as matrix_descr
+	if isStruct(t) {
+		return structTag(t)
+	}
+
+	return strings.TrimPrefix(t, "const ")
+}
+
+// SwiftParams are the parameters of the protocol method.
+func (f *funcDef) SwiftParams() string {
+	r := []string{}
+	for _, p := range f.args {
+		r = append(r, fmt.Sprintf("_ %s: %s", p.name, getSwiftParamType(p.typeName)))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// SwiftReturn is the return clause of the protocol method.
+func (f *funcDef) SwiftReturn() string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return " -> " + integer.swiftType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return ""
+	case "float", "double":
+		return " -> Self"
+	case "void *":
+		return " -> UnsafeMutableRawPointer?"
+	default:
+		return " -> " + f.ReturnType
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configTargets are the targets of the config, each the flags of an output on top of the flags shared by all,
+// such as the language, --ilp64 or --mkl-provider-crate, which are all generated in one run.
+var configTargets []map[string]json.RawMessage
+
+// flagState is the value of a flag and whether it is set, to restore the flags shared by the targets after each of them.
+type flagState struct {
+	value   string
+	values  []string
+	changed bool
+}
+
+// generateAll generates the targets of the config, or the output of the flags if the config has no targets.
+// The header is parsed once for all the targets reading it with the same macros, such as the ones without --ilp64.
+func generateAll(cmd *cobra.Command) []generatedFile {
+	if len(configTargets) == 0 {
+		return generate()
+	}
+
+	shared := make(map[*pflag.Flag]flagState)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		state := flagState{value: flag.Value.String(), changed: flag.Changed}
+		if v, isSlice := flag.Value.(pflag.SliceValue); isSlice {
+			state.values = v.GetSlice()
+		}
+		shared[flag] = state
+	})
+
+	files := []generatedFile{}
+	for i, target := range configTargets {
+		for flag, state := range shared {
+			restoreFlag(flag, state)
+		}
+		for name, raw := range target {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil || name == "config" {
+				failf(exitInput, "%s of target %d of the config %s is not a flag", name, i, configPath)
+			}
+			// the values of the target replace the shared ones, instead of adding to the lists
+			if v, isSlice := flag.Value.(pflag.SliceValue); isSlice {
+				orFail(exitInput, v.Replace(nil))
+			}
+			for _, v := range configValues(name, raw) {
+				orFail(exitInput, cmd.Flags().Set(name, v))
+			}
+		}
+		recordFlags(cmd)
+		resetRoutineState()
+
+		for _, f := range generate() {
+			if !f.pruned && slices.ContainsFunc(files, func(other generatedFile) bool { return other.path == f.path && !other.pruned }) {
+				failf(exitInput, "target %d of the config %s writes %s, which another target writes", i, configPath, f.path)
+			}
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// restoreFlag sets flag back to its value and whether it is set in state.
+func restoreFlag(flag *pflag.Flag, state flagState) {
+	if v, isSlice := flag.Value.(pflag.SliceValue); isSlice {
+		orPanic(v.Replace(state.values))
+	} else {
+		orPanic(flag.Value.Set(state.value))
+	}
+	flag.Changed = state.changed
+}
+
+// resetRoutineState clears what is recorded of the routines of a target, such as the enums they take,
+// before generating the next one.
+func resetRoutineState() {
+	clear(enumNames)
+	clear(otherEnumNames)
+	clear(enumTags)
+	clear(structNames)
+	clear(funcPointerTypes)
+	clear(vslTypes)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// typeMap is the types of the outputs for the c types, read from the json file of --type-map, such as
+//
+//	{"go": {"MKL_UINT": "uint32"}, "rust": {"MKL_UINT": "u32"}, "cc": {"MKL_UINT": "unsigned int"}}
+//
+// The go and rust types are looked up by the c types as the tool spells them, with the typedefs of the arithmetic types
+// resolved and the types of X also for const X, and take precedence over the built-in ones. The c++ types replace the names in the declared types,
+// such as MKL_UINT in const MKL_UINT *.
+type typeMap struct {
+	Go   map[string]string `json:"go"`
+	Rust map[string]string `json:"rust"`
+	Cc   map[string]string `json:"cc"`
+}
+
+var (
+	typeMapPath = ""
+	userTypes   = typeMap{}
+	// allowRawReturns keeps the return types without a mapping as the c types, which usually don't compile, instead of failing.
+	allowRawReturns = false
+)
+
+func readTypeMap(path string) typeMap {
+	r := typeMap{}
+	if err := json.Unmarshal(readFile(path), &r); err != nil {
+		failf(exitInput, "failed to read the type map %s: %v", path, err)
+	}
+
+	return r
+}
+
+// lookupType is the type of c type t in m, where the type of X is also the one of const X.
+func lookupType(m map[string]string, t string) (string, bool) {
+	if mapped, found := m[t]; found {
+		return mapped, true
+	}
+	mapped, found := m[strings.TrimPrefix(t, "const ")]
+
+	return mapped, found
+}
+
+// isUserGoType is true when go type of c type t, or of what t points to, is from the type map.
+func isUserGoType(t string) bool {
+	if _, found := lookupType(userTypes.Go, t); found {
+		return true
+	}
+	if pointee, _, isPointer := cutPointer(t); isPointer {
+		return isUserGoType(pointee)
+	}
+
+	return false
+}
+
+// ccParamType is the declared c type t with the name in it replaced by the c++ type from the type map.
+func ccParamType(t string) string {
+	if mapped, found := userTypes.Cc[t]; found {
+		return mapped
+	}
+
+	name := cBaseType(t)
+	if mapped, found := userTypes.Cc[name]; found {
+		return strings.Replace(t, name, mapped, 1)
+	}
+
+	return t
+}
+
+// CcReturnType is the return type of the c++ wrapper as declared in the header, with the types from the type map,
+// so it is the type the routine returns, such as MKL_Complex16 of a typedef of double _Complex.
+func (f *funcDef) CcReturnType() string {
+	return ccParamType(f.declReturnType)
+}
+
+// checkReturns fails if the return type of any routine has no mapping in the output, unless --allow-raw-returns is set.
+func checkReturns(funcs []funcDef, lang string, returnType func(*funcDef) (string, bool)) {
+	raw := make([]string, 0)
+	for i := range funcs {
+		if _, mapped := returnType(&funcs[i]); !mapped {
+			raw = append(raw, fmt.Sprintf("%s returns %s", funcs[i].RawName, funcs[i].ReturnType))
+		}
+	}
+
+	if allowRawReturns {
+		for _, r := range raw {
+			debugf("%s, which is kept as the c type in %s", r, lang)
+		}
+		return
+	}
+
+	if len(raw) > 0 {
+		failf(exitTemplate, "return types have no %s mapping, add them to --type-map or keep the c types with --allow-raw-returns: %s", lang, strings.Join(raw, ", "))
+	}
+}
+
+// checkPrecisions fails if a real routine takes or returns a float type other than its precision, such as double of fftwf_cost,
+// which the generic functions and the traits of the output would take as F and Self. Such routines are listed without wildcards.
+func checkPrecisions(funcs []funcDef, lang string) {
+	mixed := []string{}
+	for _, f := range funcs {
+		if !f.isReal() {
+			continue
+		}
+		if base := cBaseType(f.ReturnType); (base == "float" || base == "double") && base != f.elem.cType() {
+			mixed = append(mixed, fmt.Sprintf("%s returns %s", f.RawName, f.ReturnType))
+		}
+		for _, p := range f.args {
+			if base := cBaseType(p.typeName); (base == "float" || base == "double") && base != f.elem.cType() {
+				mixed = append(mixed, fmt.Sprintf("%s takes %s %s", f.RawName, p.typeName, p.name))
+			}
+		}
+	}
+
+	if len(mixed) > 0 {
+		failf(exitInput, "the %s output takes the float types of the real routines as their precision, list these routines without wildcards: %s", lang, strings.Join(mixed, ", "))
+	}
+}
+
+// realElems are the element types of the outputs generating only the real routines besides the plain ones,
+// and complexElems of the ones generating the complex routines as well.
+var (
+	realElems    = []elemType{float64Elem, float32Elem}
+	complexElems = []elemType{float64Elem, float32Elem, complex128Elem, complex64Elem}
+)
+
+// outputElems are the flag of the output and the element types of the routines it generates besides the plain ones,
+// or nil for the rust output, which generates the routines of all of them.
+func outputElems() (string, []elemType) {
+	outputs := []struct {
+		selected *bool
+		flag     string
+		elems    []elemType
+	}{
+		{&forC, "--for-cc", append(slices.Clone(complexElems), float16Elem, bfloat16Elem)},
+		{&forGo, "--for-go", complexElems},
+		{&forJulia, "--for-julia", complexElems},
+		{&forC11, "--for-c", realElems},
+		{&forPython, "--for-python", realElems},
+		{&forCffi, "--for-cffi", realElems},
+		{&forJava, "--for-java", realElems},
+		{&forZig, "--for-zig", realElems},
+		{&forFortran, "--for-fortran", realElems},
+		{&forSwift, "--for-swift", realElems},
+		{&forHaskell, "--for-haskell", realElems},
+		{&forOCaml, "--for-ocaml", realElems},
+		{&forKotlin, "--for-kotlin", realElems},
+		{&forLua, "--for-lua", realElems},
+		{&forNode, "--for-node", realElems},
+		{&forPascal, "--for-pascal", realElems},
+		{&forCrystal, "--for-crystal", realElems},
+		{&forAda, "--for-ada", realElems},
+		{&forR, "--for-r", realElems},
+	}
+	for _, o := range outputs {
+		if *o.selected {
+			return o.flag, o.elems
+		}
+	}
+
+	return "", nil
+}
+
+// plainComplex makes the complex routines of funcs plain functions under their names in the header, such as cblas_zgemm of cblas_%gemm
+// with --for-python, for the outputs without the complex types among their element types. They take the complex types as declared,
+// and the ones passed by value as the structs of the real and imaginary parts.
+func plainComplex(funcs []funcDef) {
+	_, elems := outputElems()
+	if elems == nil {
+		return
+	}
+
+	for i, f := range funcs {
+		if f.isComplex() && f.elem == f.out && !slices.Contains(elems, f.elem) {
+			funcs[i].elem, funcs[i].out, funcs[i].BetterName = noElem, noElem, f.RawName
+		}
+	}
+}
+
+// warnDropped warns about the routines of funcs the output leaves out, such as the half precision routines of cblas_^gemm with --for-python,
+// which are only generated by the outputs with the types of their elements. They can be generated as plain functions of the header types
+// by listing them without wildcards, such as cblas_hgemm.
+func warnDropped(funcs []funcDef) {
+	flag, elems := outputElems()
+	if elems == nil {
+		return
+	}
+
+	dropped := []string{}
+	for _, f := range funcs {
+		if f.elem != noElem && (f.elem != f.out || !slices.Contains(elems, f.elem)) {
+			dropped = append(dropped, f.RawName)
+		}
+	}
+	if len(dropped) > 0 {
+		names := []string{}
+		for _, elem := range elems {
+			names = append(names, elemName(elem))
+		}
+		warnf("%s only generates the routines on %s and the ones listed without wildcards, leaving out %s",
+			flag, strings.Join(names, ", "), strings.Join(dropped, ", "))
+	}
+}
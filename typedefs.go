@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// typedefDecl is a typedef declaration retrieved verbatim from the header.
+type typedefDecl struct {
+	name   string
+	source string
+	order  int
+}
+
+// collectTypedefs retrieves the source of all the typedefs in the translation unit, keyed by the name they define,
+// and of the struct and enum definitions, such as struct matrix_descr or enum DFTI_CONFIG_VALUE, keyed by their tags.
+func collectTypedefs(ast *cc.AST) map[string]*typedefDecl {
+	r := make(map[string]*typedefDecl)
+	order := 0
+	for tu := ast.TranslationUnit; tu != nil; tu = tu.TranslationUnit {
+		d := tu.ExternalDeclaration
+		if d == nil || d.Declaration == nil || d.Declaration.Case != cc.DeclarationDecl {
+			continue
+		}
+		if tag := definitionTag(d.Declaration); tag != "" {
+			r[tag] = &typedefDecl{name: tag, source: nodeSource(d.Declaration), order: order}
+			order++
+			continue
+		}
+		for l := d.Declaration.InitDeclaratorList; l != nil; l = l.InitDeclaratorList {
+			if l.InitDeclarator == nil || l.InitDeclarator.Declarator == nil || !l.InitDeclarator.Declarator.IsTypename() {
+				continue
+			}
+			name := l.InitDeclarator.Declarator.Name()
+			r[name] = &typedefDecl{name: name, source: nodeSource(d.Declaration), order: order}
+			order++
+		}
+	}
+
+	return r
+}
+
+// definitionTag is the tag of the struct or enum the declaration defines without declaring anything else,
+// such as matrix_descr for struct matrix_descr { ... };, or empty if it is not such a declaration.
+func definitionTag(d *cc.Declaration) string {
+	if d.InitDeclaratorList != nil {
+		return ""
+	}
+	for s := d.DeclarationSpecifiers; s != nil; s = s.DeclarationSpecifiers {
+		if s.Case != cc.DeclarationSpecifiersTypeSpec {
+			continue
+		}
+		switch t := s.TypeSpecifier; {
+		case t.Case == cc.TypeSpecifierStructOrUnion && t.StructOrUnionSpecifier.Case == cc.StructOrUnionSpecifierDef:
+			return t.StructOrUnionSpecifier.Token.SrcStr()
+		case t.Case == cc.TypeSpecifierEnum && t.EnumSpecifier.Case == cc.EnumSpecifierDef:
+			return t.EnumSpecifier.Token2.SrcStr()
+		}
+	}
+
+	return ""
+}
+
+// Typedefs are the typedefs the selected routines depend on, in the order they are declared in the header.
+func (i *tmplInput) Typedefs() []string {
+	return i.typedefs
+}
+
+// neededTypedefs are the typedefs of ast the routines funcs depend on, in the order they are declared.
+func neededTypedefs(ast *cc.AST, funcs []funcDef) []string {
+	all := collectTypedefs(ast)
+
+	needed := make(map[string]*typedefDecl)
+	var visit func(src string)
+	visit = func(src string) {
+		for _, tok := range strings.FieldsFunc(src, func(r rune) bool {
+			return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			if _, done := needed[tok]; done {
+				continue
+			}
+			if t, ok := all[tok]; ok {
+				needed[tok] = t
+				visit(t.source)
+			}
+		}
+	}
+
+	for _, f := range funcs {
+		visit(f.Declaration)
+	}
+
+	decls := make([]*typedefDecl, 0, len(needed))
+	for _, t := range needed {
+		decls = append(decls, t)
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].order < decls[j].order })
+
+	r := make([]string, 0, len(decls))
+	for _, t := range decls {
+		r = append(r, t.source)
+	}
+
+	return r
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"modernc.org/cc/v4"
+)
+
+// constDef is a named integer constant extracted from the headers, either from an object-like macro or an enumerator.
+type constDef struct {
+	Name  string
+	Value int64
+}
+
+// constGroup is a set of constants sharing the same prefix, which are emitted under the same type name.
+type constGroup struct {
+	// TypeName is the name of the type the constants are declared as.
+	TypeName string
+	// Prefix is the common prefix of the constants in the header.
+	Prefix string
+	Consts []constDef
+}
+
+// ShortName strips the leading prefix such as "VSL_" or "VML_" so the constant doesn't collide with the macro of the same name in C++.
+func (c *constDef) ShortName() string {
+	if _, after, found := strings.Cut(c.Name, "_"); found && after != "" {
+		return after
+	}
+
+	return c.Name
+}
+
+// vslConstGroups lists the VSL constants that are emitted when RNG routines are selected.
+var vslConstGroups = []constGroup{
+	{TypeName: "VslBrng", Prefix: "VSL_BRNG_"},
+	{TypeName: "VslRngMethod", Prefix: "VSL_RNG_METHOD_"},
+}
+
+// vslParams are the types of vslConstGroups the parameters of the RNG routines are taken as, by the names of the parameters,
+// such as brng of vslNewStream and method of vdRngGaussian.
+var vslParams = map[string]string{
+	"brng":   "VslBrng",
+	"method": "VslRngMethod",
+}
+
+// vslTypes are the types of vslConstGroups the output declares, which the parameters of vslParams are taken as.
+var vslTypes = make(map[string]struct{})
+
+// Distinct is true when the constants are declared as a type of their own rather than as an alias of the integer type,
+// which are the ones of vslConstGroups the RNG routines take, and not the MklConst group.
+func (g *constGroup) Distinct() bool {
+	return slices.ContainsFunc(vslConstGroups, func(vsl constGroup) bool { return vsl.TypeName == g.TypeName })
+}
+
+// recordVSLTypes records the types of vslConstGroups among groups in vslTypes.
+func recordVSLTypes(groups []*constGroup) {
+	for _, g := range groups {
+		if g.Distinct() {
+			vslTypes[g.TypeName] = struct{}{}
+		}
+	}
+}
+
+// vslParamType is the type of vslConstGroups parameter p of the RNG routine is taken as, such as VslBrng for brng of vslNewStream,
+// or empty if it takes none or the output declares no such type, as the header has no constants of it.
+func (f *funcDef) vslParamType(p funcArg) string {
+	t, isVSL := vslParams[p.name]
+	if !isVSL || !isRngRoutine(f.RawName) {
+		return ""
+	}
+	if _, declared := vslTypes[t]; !declared {
+		return ""
+	}
+	if _, isInteger := lookupInteger(p.typeName); !isInteger {
+		return ""
+	}
+
+	return t
+}
+
+// vslNonConstants are the macros sharing the prefixes that are helpers to define other values rather than values themselves.
+var vslNonConstants = map[string]struct{}{
+	"VSL_BRNG_SHIFT": {},
+	"VSL_BRNG_INC":   {},
+}
+
+// isRngRoutine checks if the routine is one of the VSL random number routines, such as vsRngGaussian or vslNewStream.
+func isRngRoutine(name string) bool {
+	if strings.HasPrefix(name, "vsl") {
+		return true
+	}
+
+	return len(name) > 2 && name[0] == 'v' && strings.HasPrefix(name[2:], "Rng")
+}
+
+// constValue converts the value of a macro or an enumerator to int64.
+func constValue(v cc.Value) (int64, bool) {
+	switch v := v.(type) {
+	case cc.Int64Value:
+		return int64(v), true
+	case cc.UInt64Value:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// collectConstants retrieves the values of the macros and enumerators starting with prefix.
+func collectConstants(ast *cc.AST, prefix string) []constDef {
+	values := make(map[string]int64)
+
+	for name, m := range ast.Macros {
+		if !strings.HasPrefix(name, prefix) || m.IsFnLike {
+			continue
+		}
+		if _, skip := vslNonConstants[name]; skip {
+			continue
+		}
+		if v, ok := constValue(m.Value()); ok {
+			values[name] = v
+		}
+	}
+
+	for name, nodes := range ast.Scope.Nodes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		for _, node := range nodes {
+			if e, ok := node.(*cc.Enumerator); ok {
+				if v, ok := constValue(e.Value()); ok {
+					values[name] = v
+				}
+			}
+		}
+	}
+
+	r := make([]constDef, 0, len(values))
+	for name, v := range values {
+		r = append(r, constDef{Name: name, Value: v})
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Value != r[j].Value {
+			return r[i].Value < r[j].Value
+		}
+		return r[i].Name < r[j].Name
+	})
+
+	return r
+}
+
+// listedConstGroup is the type name of the constants in the function list that are not in a group of vslConstGroups.
+const listedConstGroup = "MklConst"
+
+// constantValue is the value of the object-like macro or the enumerator called name.
+func constantValue(ast *cc.AST, name string) (int64, bool) {
+	if m, isMacro := ast.Macros[name]; isMacro && !m.IsFnLike {
+		return constValue(m.Value())
+	}
+	for _, node := range ast.Scope.Nodes[name] {
+		if e, ok := node.(*cc.Enumerator); ok {
+			return constValue(e.Value())
+		}
+	}
+
+	return 0, false
+}
+
+// retrieveListedConstants adds the constants in the function list to the groups,
+// to the group of vslConstGroups sharing its prefix, or otherwise to the MklConst group.
+func retrieveListedConstants(ast *cc.AST, names []string, groups []*constGroup) []*constGroup {
+	byType := make(map[string]*constGroup)
+	seen := make(map[string]struct{})
+	for _, g := range groups {
+		byType[g.TypeName] = g
+		for _, c := range g.Consts {
+			seen[c.Name] = struct{}{}
+		}
+	}
+
+	for _, name := range names {
+		if _, done := seen[name]; done {
+			continue
+		}
+		v, ok := constantValue(ast, name)
+		// the names that are neither are reported with the other entries matching nothing
+		if !ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		typeName := listedConstGroup
+		for _, g := range vslConstGroups {
+			if strings.HasPrefix(name, g.Prefix) {
+				typeName = g.TypeName
+				break
+			}
+		}
+		g, exists := byType[typeName]
+		if !exists {
+			g = &constGroup{TypeName: typeName}
+			byType[typeName] = g
+			groups = append(groups, g)
+		}
+		g.Consts = append(g.Consts, constDef{Name: name, Value: v})
+	}
+
+	return groups
+}
+
+// retrieveVSLConstants returns the VSL_BRNG_* and VSL_RNG_METHOD_* constants if any of the funcs is a RNG routine.
+func retrieveVSLConstants(ast *cc.AST, funcs []funcDef) []*constGroup {
+	hasRng := false
+	for _, f := range funcs {
+		if isRngRoutine(f.RawName) {
+			hasRng = true
+			break
+		}
+	}
+
+	if !hasRng {
+		return nil
+	}
+
+	r := make([]*constGroup, 0, len(vslConstGroups))
+	for _, g := range vslConstGroups {
+		g := g
+		g.Consts = collectConstants(ast, g.Prefix)
+		if len(g.Consts) > 0 {
+			r = append(r, &g)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the stream and RNG routines of mkl_vsl_functions.h, with the constants of mkl_vsl_defines.h
+const rngHeader = `
+typedef void *VSLStreamStatePtr;
+#define VSL_BRNG_SHIFT 20
+#define VSL_BRNG_INC (1 << VSL_BRNG_SHIFT)
+#define VSL_BRNG_MCG31 (VSL_BRNG_INC)
+#define VSL_BRNG_MT19937 (VSL_BRNG_MCG31 + VSL_BRNG_INC)
+#define VSL_RNG_METHOD_GAUSSIAN_BOXMULLER 0
+#define VSL_RNG_METHOD_GAUSSIAN_ICDF 2
+int vslNewStream(VSLStreamStatePtr *stream, const int brng, const unsigned int seed);
+int vslDeleteStream(VSLStreamStatePtr *stream);
+int vdRngGaussian(const int method, VSLStreamStatePtr stream, const int n, double r[], const double a, const double sigma);
+int vsRngGaussian(const int method, VSLStreamStatePtr stream, const int n, float r[], const float a, const float sigma);
+`
+
+const rngList = "vslNewStream\nvslDeleteStream\nv*RngGaussian\n"
+
+// the caller only builds if the wrappers take the types of the constants
+const rngGoCaller = `package caller
+
+import mkl "example.com/mkltest/mklroutines"
+
+func Gaussian(r []float64) int32 {
+	mkl.VslNewStream(nil, mkl.VSL_BRNG_MT19937, 1)
+	return mkl.VRngGaussian(mkl.VSL_RNG_METHOD_GAUSSIAN_ICDF, nil, int32(len(r)), &r[0], 0, 1)
+}
+`
+
+const rngRustCrate = `#![allow(non_snake_case)]
+pub type VSLStreamStatePtr = *mut std::ffi::c_void;
+extern "C" {
+    pub fn vslNewStream(stream: *mut VSLStreamStatePtr, brng: i32, seed: u32) -> i32;
+    pub fn vslDeleteStream(stream: *mut VSLStreamStatePtr) -> i32;
+    pub fn vdRngGaussian(method: i32, stream: VSLStreamStatePtr, n: i32, r: *mut f64, a: f64, sigma: f64) -> i32;
+    pub fn vsRngGaussian(method: i32, stream: VSLStreamStatePtr, n: i32, r: *mut f32, a: f32, sigma: f32) -> i32;
+}
+
+pub mod mkl;
+
+pub fn gaussian(r: &mut [f64]) -> i32 {
+    use mkl::MKLRoutines;
+    let mut stream: VSLStreamStatePtr = std::ptr::null_mut();
+    mkl::vslNewStream(&mut stream, mkl::VSL_BRNG_MT19937, 1);
+    f64::vRngGaussian(mkl::VSL_RNG_METHOD_GAUSSIAN_ICDF, stream, r.len() as i32, r.as_mut_ptr(), 0.0, 1.0)
+}
+`
+
+const rngCxxCaller = `#include <type_traits>
+#include "mkl.hpp"
+
+static_assert(!std::is_same<VslBrng, int>::value, "VslBrng is a type of its own");
+
+int gaussian(double *r, int n) {
+    VSLStreamStatePtr stream;
+    vslNewStream(&stream, vsl::BRNG_MT19937, 1);
+    return vRngGaussian(vsl::RNG_METHOD_GAUSSIAN_ICDF, stream, n, r, 0.0, 1.0);
+}
+`
+
+func TestVSLConstantTypes(t *testing.T) {
+	_, files := generateFromHeader(t, rngHeader, rngList, &forGo, "mkl.go")
+	output := string(files[0].content)
+	for _, want := range []string{"type VslBrng int32", "type VslRngMethod int32", "VSL_BRNG_MT19937 VslBrng = 2097152", "brng VslBrng,", "](method VslRngMethod,"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("the go output has no %s:\n%s", want, output)
+		}
+	}
+}
+
+func TestVSLConstantTypesBuild(t *testing.T) {
+	t.Run("go", func(t *testing.T) {
+		goBin, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go is not in PATH")
+		}
+		if out, err := exec.Command(goBin, "env", "CGO_ENABLED").Output(); err != nil || string(out) != "1\n" {
+			t.Skip("cgo is not enabled")
+		}
+		dir, files := generateFromHeader(t, rngHeader, rngList, &forGo, "mklroutines/mkl.go")
+		writeFiles(t, map[string]string{
+			filepath.Join(dir, "go.mod"):            "module example.com/mkltest\n\ngo 1.22\n",
+			filepath.Join(dir, "mklroutines/mkl.h"): rngHeader,
+			filepath.Join(dir, "caller/caller.go"):  rngGoCaller,
+			files[0].path:                           string(files[0].content),
+		})
+		cmd := exec.Command(goBin, "build", "./...")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "CGO_CFLAGS=-I"+filepath.Join(dir, "mklroutines"), "GOFLAGS=-mod=mod", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("the generated package and its caller fail to build: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("rust", func(t *testing.T) {
+		rustc, err := exec.LookPath("rustc")
+		if err != nil {
+			t.Skip("rustc is not in PATH")
+		}
+		forRust := false
+		dir, files := generateFromHeader(t, rngHeader, rngList, &forRust, "mkl.rs")
+		writeFiles(t, map[string]string{files[0].path: string(files[0].content), filepath.Join(dir, "lib.rs"): rngRustCrate})
+		cmd := exec.Command(rustc, "--edition", "2021", "--crate-type", "lib", "--emit", "metadata", "--out-dir", dir, "lib.rs")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("the output fails to build: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("c++", func(t *testing.T) {
+		cxx, err := exec.LookPath("g++")
+		if err != nil {
+			if cxx, err = exec.LookPath("clang++"); err != nil {
+				t.Skip("neither g++ nor clang++ is in PATH")
+			}
+		}
+		dir, files := generateFromHeader(t, rngHeader, rngList, &forC, "mkl.hpp")
+		caller := filepath.Join(dir, "caller.cc")
+		writeFiles(t, map[string]string{files[0].path: string(files[0].content), caller: rngCxxCaller})
+		if out, err := exec.Command(cxx, "-std=c++17", "-fsyntax-only", "-I", dir, caller).CombinedOutput(); err != nil {
+			t.Fatalf("the generated c++ fails to compile: %v\n%s", err, out)
+		}
+	})
+}
+
+// writeFiles writes the files by their paths, creating their directories.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
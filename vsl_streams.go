@@ -0,0 +1,47 @@
+package main
+
+import "modernc.org/cc/v4"
+
+// vslStreamRoutines are the routines managing the VSLStreamStatePtr the RNG routines draw from.
+// They take no floats, so they are generated as plain functions when RNG routines are selected.
+var vslStreamRoutines = []string{
+	"vslNewStream",
+	"vslDeleteStream",
+	"vslCopyStream",
+	"vslSkipAheadStream",
+	"vslLeapfrogStream",
+}
+
+// addStreamRoutines selects the stream routines if any RNG routine is selected, since the RNG routines cannot be called without a stream.
+// They are in the group of the RNG routines, if those are all in the same group.
+// Only the ones header ast declares are selected, as the function list does not ask for them, or all of them if ast is nil,
+// for the prefilter reading the header, which keeps the ones it finds.
+func (f *funcListInput) addStreamRoutines(ast *cc.AST) {
+	groups := make(map[string]struct{})
+	for name, fn := range f.names {
+		if isRngRoutine(name) {
+			groups[fn.group] = struct{}{}
+		}
+	}
+
+	if len(groups) == 0 {
+		return
+	}
+
+	for _, name := range vslStreamRoutines {
+		if _, found := f.names[name]; !found && !f.isExcluded(name) && declaresRoutine(ast, name) {
+			f.names[name] = funcName{betterName: name, elem: noElem, out: noElem, group: onlyGroup(groups)}
+			f.lines[name] = "(added for the RNG routines)"
+		}
+	}
+}
+
+// declaresRoutine is true if header ast declares the routine name, or ast is nil.
+func declaresRoutine(ast *cc.AST, name string) bool {
+	if ast == nil {
+		return true
+	}
+	_, found := ast.Scope.Nodes[name]
+
+	return found
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed zig.tmpl
+var zigTmplText string
+
+var forZig = false
+
+// getZigParamType returns the zig type of c type t, and if the type is the dispatched float type.
+// Types that are not known are passed as opaque pointers.
+func getZigParamType(t string, self string) (string, bool) {
+	if integer, isInteger := lookupInteger(t); isInteger {
+		return integer.zigType, false
+	}
+
+	switch t {
+	case "const double *", "const float *", "const float[]", "const double[]":
+		return fmt.Sprintf("[*c]const %s", self), true
+	case "double *", "float *", "float[]", "double[]":
+		return fmt.Sprintf("[*c]%s", self), true
+	case "double", "float", "const double", "const float":
+		return self, true
+	case "char", "const char":
+		return "u8", false
+	case "const char *":
+		return "[*c]const u8", false
+	case "int *":
+		return "[*c]c_int", false
+	case "const int *":
+		return "[*c]const c_int", false
+	case "int64_t *":
+		return "[*c]i64", false
+	case "const int64_t *":
+		return "[*c]const i64", false
+	case "CBLAS_LAYOUT", "CBLAS_UPLO", "CBLAS_DIAG", "CBLAS_TRANSPOSE", "CBLAS_SIDE",
+		"const CBLAS_LAYOUT", "const CBLAS_UPLO", "const CBLAS_DIAG", "const CBLAS_TRANSPOSE", "const CBLAS_SIDE":
+		return "c_int", false
+	}
+
+	switch {
+	case isEnum(t):
+		return "c_int", false
+	case isStruct(t):
+		return structTag(t), false
+	}
+
+	return "?*anyopaque", false
+}
+
+// zigKeywords are the zig keywords, which are quoted as @"type" when they are the names of fields.
+var zigKeywords = map[string]struct{}{
+	"addrspace": {}, "align": {}, "allowzero": {}, "and": {}, "anyframe": {}, "anytype": {}, "asm": {}, "async": {},
+	"await": {}, "break": {}, "callconv": {}, "catch": {}, "comptime": {}, "const": {}, "continue": {}, "defer": {},
+	"else": {}, "enum": {}, "errdefer": {}, "error": {}, "export": {}, "extern": {}, "fn": {}, "for": {}, "if": {},
+	"inline": {}, "linksection": {}, "noalias": {}, "noinline": {}, "nosuspend": {}, "opaque": {}, "or": {},
+	"orelse": {}, "packed": {}, "pub": {}, "resume": {}, "return": {}, "struct": {}, "suspend": {}, "switch": {},
+	"test": {}, "threadlocal": {}, "try": {}, "type": {}, "union": {}, "unreachable": {}, "usingnamespace": {},
+	"var": {}, "volatile": {}, "while": {},
+}
+
+// ZigFields are the fields of the extern struct.
+func (s *structDef) ZigFields() string {
+	r := []string{}
+	for _, field := range s.fields {
+		name := field.name
+		if _, isKeyword := zigKeywords[name]; isKeyword {
+			name = fmt.Sprintf("@\"%s\"", name)
+		}
+		t, _ := getZigParamType(field.typeName, fieldSelf(field, "f64", "f32"))
+		r = append(r, fmt.Sprintf("%s: %s", name, t))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// ZigSelf is the zig type of the float type.
+func (f *funcDef) ZigSelf() string {
+	if f.is32() {
+		return "f32"
+	}
+	return "f64"
+}
+
+// ZigParams are the parameters of the routine, with self as the float type.
+func (f *funcDef) ZigParams(self string) string {
+	r := []string{}
+	for _, p := range f.args {
+		t, _ := getZigParamType(p.typeName, self)
+		r = append(r, fmt.Sprintf("%s: %s", p.name, t))
+	}
+
+	return strings.Join(r, ", ")
+}
+
+// ZigReturn is the return type of the routine, with self as the float type.
+func (f *funcDef) ZigReturn(self string) string {
+	if integer, isInteger := lookupInteger(f.ReturnType); isInteger {
+		return integer.zigType
+	}
+
+	switch f.ReturnType {
+	case "void":
+		return "void"
+	case "float", "double":
+		return self
+	}
+	if isEnum(f.ReturnType) {
+		return "c_int"
+	}
+	if isStruct(f.ReturnType) {
+		return structTag(f.ReturnType)
+	}
+
+	return "?*anyopaque"
+}